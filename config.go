@@ -4,24 +4,34 @@ import (
 	"flag"
 	"time"
 
+	"github.com/brettbuddin/shaden/engine/stdout"
 	"github.com/brettbuddin/shaden/errors"
 )
 
 const (
 	backendPortAudio = "portaudio"
 	backendStdout    = "stdout"
+	backendWAV       = "wav"
+	backendPipe      = "pipe"
 )
 
 // Config is a structure for storing all the parsed flags.
 type Config struct {
 	Seed                 int64
 	HTTPAddr             string
+	HTTPEnabled          bool
 	REPL                 bool
+	Transactional        bool
 	FrameSize            int
 	SampleRate           float64
 	SingleSampleDisabled bool
 	FadeIn               int
+	Crossfade            int
 	Gain                 float64
+	InputChannels        int
+	ProfileWindow        time.Duration
+	OutputChannels       int
+	Workers              int
 
 	Backend string
 
@@ -31,6 +41,15 @@ type Config struct {
 	DeviceLatency   string
 	DeviceFrameSize int
 
+	Duration time.Duration
+	OutPath  string
+	BitDepth int
+
+	InPath string
+
+	Format string
+	Dither bool
+
 	ScriptPath string
 }
 
@@ -43,10 +62,16 @@ func parseArgs(args []string) (Config, error) {
 	set.IntVar(&cfg.FrameSize, "frame", 256, "frame size used within the synthesis engine")
 	set.StringVar(&cfg.HTTPAddr, "addr", ":5000", "http address to serve")
 	set.BoolVar(&cfg.REPL, "repl", false, "REPL")
+	set.BoolVar(&cfg.Transactional, "transactional", false, "only apply evaluations that succeed entirely")
 	set.Float64Var(&cfg.SampleRate, "samplerate", 44.1, "sample rate (8, 22.05, 44.1, 48.0)")
 	set.BoolVar(&cfg.SingleSampleDisabled, "disable-single-sample", false, "disables single-sample mode for feedback loops")
 	set.IntVar(&cfg.FadeIn, "fade-in", 100, "Duration of fade-in (milliseconds) once output signal is detected")
+	set.IntVar(&cfg.Crossfade, "crossfade", 0, "Duration of crossfade (milliseconds) between the old and new patch when clearing; 0 cuts to silence")
 	set.Float64Var(&cfg.Gain, "gain", 0, "gain decibels (dB)")
+	set.DurationVar(&cfg.ProfileWindow, "profile-window", 0, "enables per-unit profiling aggregated over windows of this duration")
+	set.IntVar(&cfg.InputChannels, "input-channels", 1, "number of input channels")
+	set.IntVar(&cfg.OutputChannels, "channels", 2, "number of output channels")
+	set.IntVar(&cfg.Workers, "workers", 1, "number of goroutines used to process independent parts of the patch")
 
 	set.BoolVar(&cfg.DeviceList, "device-list", false, "list all devices")
 	set.IntVar(&cfg.DeviceIn, "device-in", 0, "input device")
@@ -54,7 +79,15 @@ func parseArgs(args []string) (Config, error) {
 	set.StringVar(&cfg.DeviceLatency, "device-latency", "low", "latency setting for audio device")
 	set.IntVar(&cfg.DeviceFrameSize, "device-frame", 1024, "frame size used when writing to audio device")
 
-	set.StringVar(&cfg.Backend, "backend", "portaudio", "driver (portaudio, stdout)")
+	set.DurationVar(&cfg.Duration, "duration", 10*time.Second, "duration of audio to render (wav)")
+	set.StringVar(&cfg.OutPath, "out", "", "output file path (wav)")
+	set.IntVar(&cfg.BitDepth, "bit-depth", 16, "bit depth of rendered audio; 32 is floating point (wav)")
+
+	set.StringVar(&cfg.Format, "format", "s16le", "sample format (s16le, s24le, s32le, f32le, f64le) (stdout); pipe supports only s16le")
+	set.BoolVar(&cfg.Dither, "dither", false, "apply TPDF dither to integer sample formats (stdout)")
+	set.StringVar(&cfg.InPath, "in", "", "input file path; defaults to stdin (pipe)")
+
+	set.StringVar(&cfg.Backend, "backend", "portaudio", "driver (portaudio, stdout, wav, pipe)")
 
 	err := set.Parse(args)
 
@@ -62,13 +95,53 @@ func parseArgs(args []string) (Config, error) {
 		cfg.ScriptPath = set.Arg(0)
 	}
 
+	// Offline renders don't serve HTTP unless asked to; so renders can run alongside each other, or a live session.
+	cfg.HTTPEnabled = cfg.Backend != backendWAV
+	set.Visit(func(f *flag.Flag) {
+		if f.Name == "addr" {
+			cfg.HTTPEnabled = true
+		}
+	})
+
 	switch cfg.Backend {
 	case "portaudio":
 	case "stdout":
+		if _, err := stdout.ParseFormat(cfg.Format); err != nil {
+			return cfg, err
+		}
+	case "wav":
+		if cfg.OutPath == "" {
+			return cfg, errors.Errorf("out cannot be empty when using the wav backend")
+		}
+		if cfg.Duration <= 0 {
+			return cfg, errors.Errorf("duration must be greater than zero")
+		}
+		switch cfg.BitDepth {
+		case 16, 24, 32:
+		default:
+			return cfg, errors.Errorf("unsupported bit depth %d", cfg.BitDepth)
+		}
+	case "pipe":
+		if f, err := stdout.ParseFormat(cfg.Format); err != nil || f != stdout.FormatS16LE {
+			return cfg, errors.Errorf("pipe backend only supports the s16le sample format")
+		}
 	default:
 		return cfg, errors.Errorf("unknown backend %q", cfg.Backend)
 	}
 
+	if cfg.Crossfade < 0 {
+		return cfg, errors.Errorf("crossfade cannot be negative")
+	}
+	if cfg.InputChannels < 1 {
+		return cfg, errors.Errorf("input channels must be greater than zero")
+	}
+	if cfg.OutputChannels < 1 {
+		return cfg, errors.Errorf("channels must be greater than zero")
+	}
+	if cfg.Workers < 1 {
+		return cfg, errors.Errorf("workers must be greater than zero")
+	}
+
 	if cfg.HTTPAddr == "" {
 		return cfg, errors.Errorf("addr cannot be empty")
 	}
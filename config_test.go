@@ -3,6 +3,7 @@ package main
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -108,6 +109,79 @@ func TestParseArgs_Available(t *testing.T) {
 				assert.Equal(t, -6.0, cfg.Gain)
 			},
 		},
+		{
+			args: []string{"-channels", "8"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, 8, cfg.OutputChannels)
+			},
+		},
+		{
+			args: []string{"-crossfade", "500"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, 500, cfg.Crossfade)
+			},
+		},
+		{
+			args: []string{"-workers", "4"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, 4, cfg.Workers)
+			},
+		},
+		{
+			args: []string{"-transactional"},
+			check: func(t *testing.T, cfg Config) {
+				assert.True(t, cfg.Transactional)
+			},
+		},
+		{
+			args: []string{"-profile-window", "2s"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, 2*time.Second, cfg.ProfileWindow)
+			},
+		},
+		{
+			args: []string{"-input-channels", "4"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, 4, cfg.InputChannels)
+			},
+		},
+		{
+			args: []string{"-backend", "stdout", "-format", "f32le", "-dither"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, backendStdout, cfg.Backend)
+				assert.Equal(t, "f32le", cfg.Format)
+				assert.True(t, cfg.Dither)
+			},
+		},
+		{
+			args: []string{"-backend", "pipe", "-in", "in.raw"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, backendPipe, cfg.Backend)
+				assert.Equal(t, "in.raw", cfg.InPath)
+			},
+		},
+		{
+			args: []string{"-backend", "wav", "-out", "out.wav", "-duration", "5s", "-bit-depth", "24"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, backendWAV, cfg.Backend)
+				assert.Equal(t, "out.wav", cfg.OutPath)
+				assert.Equal(t, 5*time.Second, cfg.Duration)
+				assert.Equal(t, 24, cfg.BitDepth)
+				assert.False(t, cfg.HTTPEnabled)
+			},
+		},
+		{
+			args: []string{"-backend", "wav", "-out", "out.wav", "-duration", "5s", "-addr", ":3000"},
+			check: func(t *testing.T, cfg Config) {
+				assert.True(t, cfg.HTTPEnabled)
+			},
+		},
+		{
+			args: []string{"-backend", "stdout"},
+			check: func(t *testing.T, cfg Config) {
+				assert.True(t, cfg.HTTPEnabled)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -136,6 +210,38 @@ func TestParseArgs_Erroneous(t *testing.T) {
 			name: "frame size not a multiple of device frame size",
 			args: []string{"-frame", "100", "-device-frame", "1024"},
 		},
+		{
+			name: "zero output channels",
+			args: []string{"-channels", "0"},
+		},
+		{
+			name: "negative crossfade",
+			args: []string{"-crossfade", "-1"},
+		},
+		{
+			name: "zero workers",
+			args: []string{"-workers", "0"},
+		},
+		{
+			name: "zero input channels",
+			args: []string{"-input-channels", "0"},
+		},
+		{
+			name: "stdout backend with unknown format",
+			args: []string{"-backend", "stdout", "-format", "u8"},
+		},
+		{
+			name: "pipe backend with format other than s16le",
+			args: []string{"-backend", "pipe", "-format", "f32le"},
+		},
+		{
+			name: "wav backend without output path",
+			args: []string{"-backend", "wav"},
+		},
+		{
+			name: "wav backend with unsupported bit depth",
+			args: []string{"-backend", "wav", "-out", "out.wav", "-bit-depth", "8"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
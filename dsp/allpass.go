@@ -32,3 +32,17 @@ func (a *AllPass) TickAbsolute(in, gain, delay float64) float64 {
 func (a *AllPass) TickRelative(in, gain, scale float64) float64 {
 	return a.TickAbsolute(in, gain, float64(len(a.dl.buffer))*scale)
 }
+
+// Thiran is a first-order allpass filter that delays a signal by a fraction of a sample; unlike linear interpolation
+// it doesn't attenuate high frequencies. Its delay is most accurate between 0.5 and 1.5 samples.
+type Thiran struct {
+	lastIn, lastOut float64
+}
+
+// Tick advances the filter's state with a delay in samples
+func (t *Thiran) Tick(in, delay float64) float64 {
+	a := (1 - delay) / (1 + delay)
+	out := a*in + t.lastIn - a*t.lastOut
+	t.lastIn, t.lastOut = in, out
+	return out
+}
@@ -43,3 +43,17 @@ func TestAllPass_TickRelative(t *testing.T) {
 	}
 	require.Equal(t, []float64{0, 0.5, 1, 1.5, 2, 3.5, 4.75, 6, 7.25, 8.5}, out)
 }
+
+func TestThiran_Tick(t *testing.T) {
+	// A ramp is delayed by the fractional delay once the filter has settled.
+	for _, delay := range []float64{0.5, 0.75, 1, 1.25, 1.5} {
+		var (
+			ap  Thiran
+			out float64
+		)
+		for i := 0; i < 100; i++ {
+			out = ap.Tick(float64(i), delay)
+		}
+		require.InDelta(t, 99-delay, out, 1e-9)
+	}
+}
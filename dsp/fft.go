@@ -0,0 +1,47 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// FFT computes the discrete Fourier transform of a signal in place. The length of the signal must be a power of two.
+func FFT(x []complex128) {
+	fft(x, -1)
+}
+
+// IFFT computes the inverse discrete Fourier transform of a spectrum in place. The length of the spectrum must be a
+// power of two.
+func IFFT(x []complex128) {
+	fft(x, 1)
+	scale := complex(1/float64(len(x)), 0)
+	for i := range x {
+		x[i] *= scale
+	}
+}
+
+// fft is an iterative radix-2 Cooley-Tukey transform; sign determines the direction of the transform.
+func fft(x []complex128, sign float64) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, sign*2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFFT(t *testing.T) {
+	const n = 16
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*3*float64(i)/n), 0)
+	}
+	FFT(x)
+	for k, v := range x {
+		if k == 3 || k == n-3 {
+			require.InDelta(t, n/2, cmplx.Abs(v), 1e-9)
+		} else {
+			require.InDelta(t, 0, cmplx.Abs(v), 1e-9)
+		}
+	}
+}
+
+func TestIFFT(t *testing.T) {
+	x := []complex128{1, 2, 3, 4, 5, 6, 7, 8}
+	y := append([]complex128{}, x...)
+	FFT(y)
+	IFFT(y)
+	for i := range x {
+		require.InDelta(t, real(x[i]), real(y[i]), 1e-9)
+		require.InDelta(t, 0, imag(y[i]), 1e-9)
+	}
+}
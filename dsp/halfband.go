@@ -0,0 +1,91 @@
+package dsp
+
+import "math"
+
+// halfbandTaps is the length of the Halfband filter's kernel. Every other tap, aside from the center tap, is zero.
+const halfbandTaps = 47
+
+var halfbandKernel = newHalfbandKernel()
+
+// newHalfbandKernel returns a Blackman windowed sinc kernel with its cutoff at a quarter of the sample rate; normalized
+// to unity gain at DC.
+func newHalfbandKernel() [halfbandTaps]float64 {
+	var (
+		kernel [halfbandTaps]float64
+		center = halfbandTaps / 2
+		sum    float64
+	)
+	for k := range kernel {
+		m := k - center
+		switch {
+		case m == 0:
+			kernel[k] = 0.5
+		case m%2 == 0:
+			continue
+		default:
+			var (
+				x = float64(k) / float64(halfbandTaps-1)
+				w = 0.42 - 0.5*math.Cos(2*math.Pi*x) + 0.08*math.Cos(4*math.Pi*x)
+			)
+			kernel[k] = math.Sin(math.Pi*float64(m)/2) / (math.Pi * float64(m)) * w
+		}
+		sum += kernel[k]
+	}
+	for k := range kernel {
+		kernel[k] /= sum
+	}
+	return kernel
+}
+
+// Halfband is a lowpass FIR filter that halves the sample rate of a signal; removing the content that would alias
+// once it's been halved.
+type Halfband struct {
+	history [halfbandTaps]float64
+	pos     int
+}
+
+// Tick advances the filter's state by two samples; returning a single sample at half the rate.
+func (h *Halfband) Tick(a, b float64) float64 {
+	h.push(a)
+	h.push(b)
+	var sum float64
+	for k, c := range halfbandKernel {
+		if c == 0 {
+			continue
+		}
+		sum += c * h.history[(h.pos+halfbandTaps-1-k)%halfbandTaps]
+	}
+	return sum
+}
+
+func (h *Halfband) push(v float64) {
+	h.history[h.pos] = v
+	h.pos = (h.pos + 1) % halfbandTaps
+}
+
+// Decimator reduces the sample rate of an oversampled signal by a power of two; with a cascade of Halfband filters.
+type Decimator struct {
+	stages []Halfband
+}
+
+// NewDecimator returns a new Decimator that reduces the sample rate by a factor, which must be a power of two.
+func NewDecimator(factor int) *Decimator {
+	var stages int
+	for n := factor; n > 1; n /= 2 {
+		stages++
+	}
+	return &Decimator{stages: make([]Halfband, stages)}
+}
+
+// Tick reduces a sample's worth of oversampled samples to a single sample. The length of samples must be the factor of
+// the Decimator; its contents are overwritten.
+func (d *Decimator) Tick(samples []float64) float64 {
+	n := len(samples)
+	for s := range d.stages {
+		n /= 2
+		for i := 0; i < n; i++ {
+			samples[i] = d.stages[s].Tick(samples[2*i], samples[2*i+1])
+		}
+	}
+	return samples[0]
+}
@@ -0,0 +1,51 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHalfband(t *testing.T) {
+	// level returns the amplitude of a sine, at a frequency relative to the rate of the input, once halved.
+	level := func(freq float64) float64 {
+		var (
+			h        Halfband
+			sum      float64
+			measured int
+		)
+		for i := 0; i < 1000; i++ {
+			var (
+				a = math.Sin(2 * math.Pi * freq * float64(2*i))
+				b = math.Sin(2 * math.Pi * freq * float64(2*i+1))
+				v = h.Tick(a, b)
+			)
+			if i > halfbandTaps {
+				sum += v * v
+				measured++
+			}
+		}
+		return math.Sqrt(2 * sum / float64(measured))
+	}
+
+	require.InDelta(t, 1, level(0.05), 0.01, "passband")
+	require.True(t, level(0.4) < 0.001, "content that would alias is removed")
+}
+
+func TestDecimator(t *testing.T) {
+	for _, factor := range []int{1, 2, 4, 8} {
+		var (
+			d       = NewDecimator(factor)
+			samples = make([]float64, factor)
+			v       float64
+		)
+		for i := 0; i < 100; i++ {
+			for j := range samples {
+				samples[j] = 0.5
+			}
+			v = d.Tick(samples)
+		}
+		require.InDelta(t, 0.5, v, 1e-9, "unity gain at DC with a factor of %d", factor)
+	}
+}
@@ -0,0 +1,146 @@
+package dsp
+
+import "math"
+
+// MaxResonance is the most resonance a filter model allows; beyond 1 the filter self-oscillates, and its saturation
+// limits the level of the oscillation.
+const MaxResonance = 1.5
+
+// diodeResonance is the feedback at which the diode ladder self-oscillates; the gain of the ladder, at the frequency
+// its phase is inverted, is 1/17. That frequency is √2 times its natural frequency.
+const (
+	diodeResonance = 17
+	diodeFreq      = math.Sqrt2
+)
+
+// Ladder is a zero-delay-feedback model of a 4-pole transistor ladder lowpass filter. Its input, less the resonance
+// fed back from its output, is saturated. Cutoff is a frequency normalized to the sample rate, below 0.5. Resonance
+// ranges from 0 to 1, where the filter begins to self-oscillate at its cutoff; see MaxResonance.
+type Ladder struct {
+	lastCutoff, g float64
+	state         [4]float64
+}
+
+// Tick advances the filter's state
+func (f *Ladder) Tick(in, cutoff, resonance, drive float64) float64 {
+	if cutoff != f.lastCutoff {
+		f.g = math.Tan(math.Pi * cutoff)
+		f.lastCutoff = cutoff
+	}
+
+	var (
+		g = f.g / (1 + f.g)
+		k = 4 * Clamp(resonance, 0, MaxResonance)
+	)
+
+	// The output of the ladder is a linear function of its input: a*u + b.
+	var a, b float64 = 1, 0
+	for _, s := range f.state {
+		a *= g
+		b = g*b + s*(1-g)
+	}
+	u := math.Tanh((drive*in - k*b) / (1 + k*a))
+
+	for i, s := range f.state {
+		v := (u - s) * g
+		u = v + s
+		f.state[i] = u + v
+	}
+	return u
+}
+
+// DiodeLadder is a zero-delay-feedback model of a 4-pole diode ladder lowpass filter. Unlike the transistor ladder,
+// its stages load each other, and its last capacitor is half the size of the others. Its parameters are the same as
+// those of the Ladder.
+type DiodeLadder struct {
+	lastCutoff, g float64
+	state         [4]float64
+}
+
+// Tick advances the filter's state
+func (f *DiodeLadder) Tick(in, cutoff, resonance, drive float64) float64 {
+	if cutoff != f.lastCutoff {
+		f.g = math.Tan(math.Pi*cutoff) / diodeFreq
+		f.lastCutoff = cutoff
+	}
+
+	var (
+		g = f.g
+		k = diodeResonance * Clamp(resonance, 0, MaxResonance)
+
+		// The stages are the solution of a tridiagonal system, which is linear in their input: a*u + b.
+		a = solveDiodeLadder(g, [4]float64{g, 0, 0, 0})
+		b = solveDiodeLadder(g, f.state)
+		u = math.Tanh((drive*in - k*b[3]) / (1 + k*a[3]))
+	)
+
+	for i := range f.state {
+		y := a[i]*u + b[i]
+		f.state[i] = 2*y - f.state[i]
+	}
+	return a[3]*u + b[3]
+}
+
+// solveDiodeLadder solves the trapezoidal integration of the stages of the diode ladder, with an integrator gain of g,
+// for the right hand side d; using the Thomas algorithm.
+func solveDiodeLadder(g float64, d [4]float64) [4]float64 {
+	var (
+		lower = [4]float64{0, -g, -g, -2 * g}
+		diag  = [4]float64{1 + 2*g, 1 + 2*g, 1 + 2*g, 1 + 2*g}
+		upper = [4]float64{-g, -g, -g, 0}
+	)
+	for i := 1; i < 4; i++ {
+		m := lower[i] / diag[i-1]
+		diag[i] -= m * upper[i-1]
+		d[i] -= m * d[i-1]
+	}
+	var y [4]float64
+	y[3] = d[3] / diag[3]
+	for i := 2; i >= 0; i-- {
+		y[i] = (d[i] - upper[i]*y[i+1]) / diag[i]
+	}
+	return y
+}
+
+// SallenKey is a zero-delay-feedback model of the 2-pole Sallen-Key lowpass filter found in the later MS-20: two
+// lowpass stages, with the output fed back between them through a highpass stage. The signal between the stages is
+// saturated. Its parameters are the same as those of the Ladder.
+type SallenKey struct {
+	lastCutoff, g  float64
+	lp1, lp2, hp   float64
+	lastResonance  float64
+	alpha, lpScale float64
+}
+
+// Tick advances the filter's state
+func (f *SallenKey) Tick(in, cutoff, resonance, drive float64) float64 {
+	k := 2 * Clamp(resonance, 0, MaxResonance)
+	if cutoff != f.lastCutoff || k != f.lastResonance {
+		f.g = math.Tan(math.Pi * cutoff)
+		f.lastCutoff, f.lastResonance = cutoff, k
+		g := f.g / (1 + f.g)
+		f.alpha = 1 / (1 - k*g + k*g*g)
+		f.lpScale = k * (1 - g)
+	}
+	g := f.g / (1 + f.g)
+
+	// First lowpass stage
+	v := (drive*in - f.lp1) * g
+	y1 := v + f.lp1
+	f.lp1 = y1 + v
+
+	// The input of the second stage includes the highpassed output; solved for ahead of time.
+	u := math.Tanh(f.alpha * (y1 + (f.lpScale*f.lp2-f.hp)*(1-g)))
+
+	// Second lowpass stage
+	v = (u - f.lp2) * g
+	y := v + f.lp2
+	f.lp2 = y + v
+
+	// Highpass stage in the feedback path
+	v = (k*y - f.hp) * g
+	lp := v + f.hp
+	f.hp = lp + v
+
+	return y
+}
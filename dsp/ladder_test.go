@@ -0,0 +1,76 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type filterModel interface {
+	Tick(in, cutoff, resonance, drive float64) float64
+}
+
+var filterModels = []struct {
+	name        string
+	new         func() filterModel
+	attenuation float64 // Gain a decade above the cutoff
+}{
+	{"ladder", func() filterModel { return &Ladder{} }, 0.001},
+	{"diode-ladder", func() filterModel { return &DiodeLadder{} }, 0.001},
+	{"sallen-key", func() filterModel { return &SallenKey{} }, 0.02},
+}
+
+func TestFilterModels_Lowpass(t *testing.T) {
+	for _, model := range filterModels {
+		t.Run(model.name, func(t *testing.T) {
+			f := model.new()
+			var out float64
+			for i := 0; i < 10000; i++ {
+				out = f.Tick(0.01, 0.01, 0, 1)
+			}
+			require.InDelta(t, math.Tanh(0.01), out, 1e-6, "unity gain at DC")
+
+			f = model.new()
+			var peak float64
+			for i := 0; i < 10000; i++ {
+				out = f.Tick(0.01*math.Sin(2*math.Pi*0.1*float64(i)), 0.01, 0, 1)
+				if i > 5000 {
+					peak = math.Max(peak, math.Abs(out))
+				}
+			}
+			require.True(t, peak < 0.01*model.attenuation, "gain a decade above the cutoff: %f", peak/0.01)
+		})
+	}
+}
+
+func TestFilterModels_SelfOscillation(t *testing.T) {
+	const cutoff = 0.01
+	for _, model := range filterModels {
+		t.Run(model.name, func(t *testing.T) {
+			var (
+				f         = model.new()
+				peak      float64
+				crossings int
+				last      float64
+			)
+			for i := 0; i < 40000; i++ {
+				var in float64
+				if i == 0 {
+					in = 0.1
+				}
+				out := f.Tick(in, cutoff, 1.2, 1)
+				if i >= 20000 {
+					peak = math.Max(peak, math.Abs(out))
+					if last < 0 && out >= 0 {
+						crossings++
+					}
+				}
+				last = out
+			}
+			require.True(t, peak > 0.02, "oscillation decayed: %f", peak)
+			require.True(t, peak < 2, "oscillation unbounded: %f", peak)
+			require.InEpsilon(t, cutoff*20000, float64(crossings), 0.1)
+		})
+	}
+}
@@ -5,9 +5,9 @@ import (
 	"math/rand"
 )
 
-// RandRange returns random values between a specified range
-func RandRange(min, max float64) float64 {
-	return rand.Float64()*(max-min) + min
+// RandRange returns random values from a random source between a specified range
+func RandRange(r *rand.Rand, min, max float64) float64 {
+	return r.Float64()*(max-min) + min
 }
 
 // ExpRatio produces an (inverse-)exponential curve that's inflection can be controlled by a specific ratio
@@ -1,14 +1,16 @@
 package dsp
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
 func TestRandRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
 	for i := 0; i < 1000; i++ {
-		v := RandRange(0, 20)
+		v := RandRange(r, 0, 20)
 		require.True(t, v >= 0)
 		require.True(t, v <= 20.0)
 	}
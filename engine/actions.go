@@ -1,12 +1,19 @@
 package engine
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/brettbuddin/shaden/errors"
 	"github.com/brettbuddin/shaden/unit"
 )
 
-// Clear is an action that resets the Engine's state.
+// Clear is an action that resets the Engine's state. Within a Batch, the replaced graph is only closed once the Batch
+// has been applied; so it can be restored if the Batch fails.
 func Clear(e *Engine) (interface{}, error) {
+	if e.batching {
+		return e.graph.replace(e.crossfade, e.fadeIn, e.frameSize, e.backend.SampleRate())
+	}
 	return nil, e.Reset()
 }
 
@@ -24,32 +31,69 @@ func UnmountUnit(u *unit.Unit) func(*Graph) (interface{}, error) {
 	}
 }
 
-// EmitOutputs sinks 1 or 2 outputs to the Engine.
-func EmitOutputs(left, right unit.OutRef) func(*Graph) (interface{}, error) {
+// DetachUnit removes a Unit from the audio graph without closing it; so it can be mounted again, such as when a change
+// is undone. The Unit must eventually be closed with CloseDetachedUnits.
+func DetachUnit(u *unit.Unit) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		return nil, g.Detach(u)
+	}
+}
+
+// CloseDetachedUnits closes Units removed from the audio graph by DetachUnit; unless they've been mounted again.
+func CloseDetachedUnits(units ...*unit.Unit) func(*Graph) (interface{}, error) {
 	return func(g *Graph) (interface{}, error) {
-		leftOut, ok := left.Unit.Out[left.Output]
-		if !ok {
-			return nil, errors.Errorf("unit %q has no output %q", left.Unit.ID, left.Output)
+		return nil, g.CloseDetached(units...)
+	}
+}
+
+// EmitOutputs sinks outputs to the Engine's output channels. A single output is sent to all channels; otherwise each
+// output is sent to the channel at the same position, and the channels after the last output are silenced.
+func EmitOutputs(refs ...unit.OutRef) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		if len(refs) == 0 {
+			return nil, errors.New("no outputs to emit")
+		}
+		if len(refs) > g.outputChannels {
+			return nil, errors.Errorf("%d outputs exceeds the %d output channels", len(refs), g.outputChannels)
 		}
-		var rightOut unit.Output
-		if right.Unit == nil {
-			rightOut = leftOut
-		} else {
-			var ok bool
-			rightOut, ok = right.Unit.Out[right.Output]
+
+		outs := make([]unit.Output, len(refs))
+		for i, ref := range refs {
+			out, ok := ref.Unit.Out[ref.Output]
 			if !ok {
-				return nil, errors.Errorf("unit %s has no output %q", right.Unit.ID, right.Output)
+				return nil, errors.Errorf("unit %q has no output %q", ref.Unit.ID, ref.Output)
+			}
+			outs[i] = out
+		}
+		channels := len(outs)
+		if channels == 1 {
+			channels = g.outputChannels
+		}
+		for i := 0; i < channels; i++ {
+			out := outs[0]
+			if len(outs) > 1 {
+				out = outs[i]
+			}
+			in := g.sink.In[strconv.Itoa(i)]
+			if err := unit.Unpatch(g.graph, in); err != nil {
+				return nil, errors.Wrap(err, "unpatch")
+			}
+			if err := unit.Patch(g.graph, out, in); err != nil {
+				return nil, errors.Wrap(err, "patch")
 			}
 		}
-		if err := unit.Patch(g.graph, leftOut, g.sink.In["l"]); err != nil {
-			return nil, errors.Wrap(err, "patch")
+		for i := channels; i < g.outputChannels; i++ {
+			if err := unit.Unpatch(g.graph, g.sink.In[strconv.Itoa(i)]); err != nil {
+				return nil, errors.Wrap(err, "unpatch")
+			}
 		}
-		return nil, unit.Patch(g.graph, rightOut, g.sink.In["r"])
+		return nil, nil
 	}
 }
 
 // PatchInput patches values into a Unit's Ins. If `forceReset` is set to `true` all Ins on that Unit that haven't been
-// referenced in `inputs` will be reset to their default values.
+// referenced in `inputs` will be reset to their default values. The result is the FeedbackLoop the Unit is part of once
+// patched, if any.
 func PatchInput(u *unit.Unit, inputs map[string]interface{}, forceReset bool) func(*Graph) (interface{}, error) {
 	seen := make(map[string]struct{}, len(u.In))
 	return func(g *Graph) (interface{}, error) {
@@ -78,6 +122,76 @@ func PatchInput(u *unit.Unit, inputs map[string]interface{}, forceReset bool) fu
 				}
 			}
 		}
+		if loop := unit.FeedbackLoop(g.graph, u); loop != nil {
+			return FeedbackLoop(loop), nil
+		}
 		return nil, nil
 	}
 }
+
+// FeedbackLoop is the units along a feedback loop. Units along a feedback loop are processed a sample at a time, unless
+// one of the connections along it is delayed by a block.
+type FeedbackLoop []*unit.Unit
+
+func (l FeedbackLoop) String() string {
+	ids := make([]string, len(l))
+	for i, u := range l {
+		ids[i] = u.ID
+	}
+	return strings.Join(ids, ", ")
+}
+
+// FeedbackLoops is an action that returns the FeedbackLoops in the graph.
+func FeedbackLoops(g *Graph) (interface{}, error) {
+	return g.FeedbackLoops(), nil
+}
+
+// Batch is an action that applies several actions within a single audio callback. Each action may be any action
+// accepted by the Engine. The result is a slice containing the result of each action. Processing stops at the first
+// action to fail; the actions applied before it are rolled back, in reverse order, before the error is returned. Only
+// the changes of Undoable actions, and the graph replaced by Clear, can be rolled back.
+func Batch(actions ...interface{}) func(*Engine) (interface{}, error) {
+	return func(e *Engine) (interface{}, error) {
+		batching := e.batching
+		e.batching = true
+		defer func() { e.batching = batching }()
+
+		results := make([]interface{}, len(actions))
+		for i, action := range actions {
+			data, err := e.call(action)
+			if err != nil {
+				err = errors.Wrapf(err, "batch action %d", i)
+				if rollbackErr := rollback(e.graph, results[:i]); rollbackErr != nil {
+					return nil, errors.Wrapf(rollbackErr, "rolling back after %s", err)
+				}
+				return nil, err
+			}
+			results[i] = data
+		}
+		for i, r := range results {
+			if r, ok := r.(*replacedGraph); ok {
+				results[i] = nil
+				if err := e.graph.settle(r); err != nil {
+					e.report(errors.Wrap(err, "closing cleared graph"))
+				}
+			}
+		}
+		return results, nil
+	}
+}
+
+// rollback undoes the Changes among the results of applied actions, in reverse order; restoring any graph replaced by
+// Clear.
+func rollback(g *Graph, results []interface{}) error {
+	for i := len(results) - 1; i >= 0; i-- {
+		switch r := results[i].(type) {
+		case Change:
+			if _, err := r.Undo()(g); err != nil {
+				return errors.Wrapf(err, "undo batch action %d", i)
+			}
+		case *replacedGraph:
+			g.restore(r)
+		}
+	}
+	return nil
+}
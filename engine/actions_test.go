@@ -75,7 +75,7 @@ func TestPatch(t *testing.T) {
 
 func TestEmitOutputs(t *testing.T) {
 	g := NewGraph(frameSize)
-	err := g.createSink(100, frameSize, sampleRate)
+	err := g.createSink(100, frameSize, sampleRate, false)
 	require.NoError(t, err)
 
 	io1 := unit.NewIO("dummy1", frameSize)
@@ -97,8 +97,161 @@ func TestEmitOutputs(t *testing.T) {
 
 	_, err = EmitOutputs(left, right)(g)
 	require.NoError(t, err)
-	require.True(t, g.sink.In["l"].HasSource())
-	require.True(t, g.sink.In["r"].HasSource())
+	require.True(t, g.sink.In["0"].HasSource())
+	require.True(t, g.sink.In["1"].HasSource())
 	require.Equal(t, 1, unit1.Out["out"].Out().DestinationCount())
 	require.Equal(t, 1, unit2.Out["out"].Out().DestinationCount())
+
+	_, err = EmitOutputs(left, right, left)(g)
+	require.Error(t, err)
+}
+
+func TestEmitOutputs_Multichannel(t *testing.T) {
+	g := NewGraph(frameSize)
+	g.outputChannels = 4
+	err := g.createSink(100, frameSize, sampleRate, false)
+	require.NoError(t, err)
+	require.Len(t, g.out, 4)
+
+	io1 := unit.NewIO("dummy1", frameSize)
+	io1.NewOut("out")
+	unit1 := unit.NewUnit(io1, nil)
+	err = g.Mount(unit1)
+	require.NoError(t, err)
+
+	io2 := unit.NewIO("dummy2", frameSize)
+	io2.NewOut("out")
+	unit2 := unit.NewUnit(io2, nil)
+	err = g.Mount(unit2)
+	require.NoError(t, err)
+
+	// A single output is sent to all channels
+	_, err = EmitOutputs(unit.OutRef{Unit: unit1, Output: "out"})(g)
+	require.NoError(t, err)
+	require.Equal(t, 4, unit1.Out["out"].Out().DestinationCount())
+
+	// Multiple outputs are sent to channels positionally
+	_, err = EmitOutputs(
+		unit.OutRef{Unit: unit2, Output: "out"},
+		unit.OutRef{Unit: unit2, Output: "out"},
+		unit.OutRef{Unit: unit2, Output: "out"},
+	)(g)
+	require.NoError(t, err)
+	require.Equal(t, 3, unit2.Out["out"].Out().DestinationCount())
+	require.Zero(t, unit1.Out["out"].Out().DestinationCount(), "channels after the last output are silenced")
+	require.False(t, g.sink.In["3"].HasSource())
+
+	// Emitting fewer outputs than before leaves none of the previous ones patched
+	_, err = EmitOutputs(
+		unit.OutRef{Unit: unit1, Output: "out"},
+		unit.OutRef{Unit: unit2, Output: "out"},
+	)(g)
+	require.NoError(t, err)
+	_, err = EmitOutputs(unit.OutRef{Unit: unit1, Output: "out"})(g)
+	require.NoError(t, err)
+	require.Equal(t, 4, unit1.Out["out"].Out().DestinationCount())
+	require.Zero(t, unit2.Out["out"].Out().DestinationCount())
 }
+
+func TestBatch(t *testing.T) {
+	be := backend{
+		start:     func(func([][]float32, [][]float32)) error { return nil },
+		stop:      func() error { return nil },
+		frameSize: frameSize,
+	}
+	e, err := New(be, frameSize)
+	require.NoError(t, err)
+
+	io := unit.NewIO("dummy", frameSize)
+	io.NewIn("in", dsp.Float64(0))
+	u := unit.NewUnit(io, nil)
+
+	data, err := Batch(
+		MountUnit(u),
+		PatchInput(u, map[string]interface{}{"in": 2.0}, false),
+	)(e)
+	require.NoError(t, err)
+	require.Len(t, data, 2)
+	require.Equal(t, 5, e.graph.Size())
+	require.Equal(t, 2.0, io.In["in"].Read(0))
+
+	other := unit.NewUnit(unit.NewIO("dummy", frameSize), nil)
+	data, err = Batch(
+		Undoable(MountUnit(other), other),
+		Undoable(PatchInput(u, map[string]interface{}{"in": 3.0}, false), u),
+		PatchInput(u, map[string]interface{}{"missing": 1.0}, false),
+		Clear,
+	)(e)
+	require.Error(t, err)
+	require.Nil(t, data)
+	require.Equal(t, 5, e.graph.Size())
+	require.False(t, other.AttachedTo(e.graph.graph))
+	require.Equal(t, 2.0, io.In["in"].Read(0))
+}
+
+func TestFeedbackLoops(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		a  = incrementUnit()
+		b  = incrementUnit()
+		ax = a.In["x"]
+		bx = b.In["x"]
+	)
+	require.NoError(t, g.Mount(a))
+	require.NoError(t, g.Mount(b))
+	_, err := PatchInput(a, map[string]interface{}{"x": unit.OutRef{Unit: b, Output: "out"}}, false)(g)
+	require.NoError(t, err)
+
+	// Delaying the connection that closes the loop keeps the loop from being processed a sample at a time.
+	v, err := PatchInput(b, map[string]interface{}{
+		"x": unit.FeedbackRef{OutRef: unit.OutRef{Unit: a, Output: "out"}},
+	}, false)(g)
+	require.NoError(t, err)
+	require.Nil(t, v)
+	require.True(t, bx.Delayed())
+	require.Empty(t, g.FeedbackLoops())
+
+	g.Sort()
+	require.Equal(t, unit.Block, ax.Mode)
+	require.Equal(t, unit.Block, bx.Mode)
+	out := a.Out["out"].Out()
+	for n := 1; n <= 3; n++ {
+		g.process(frameSize)
+		for i := 0; i < frameSize; i++ {
+			require.Equal(t, float64(2*n), out.Read(i))
+		}
+	}
+
+	v, err = PatchInput(b, map[string]interface{}{"x": unit.OutRef{Unit: a, Output: "out"}}, false)(g)
+	require.NoError(t, err)
+	require.False(t, bx.Delayed())
+	require.ElementsMatch(t, FeedbackLoop{a, b}, v)
+	loops, err := FeedbackLoops(g)
+	require.NoError(t, err)
+	require.Len(t, loops, 1)
+	require.ElementsMatch(t, FeedbackLoop{a, b}, loops.([]FeedbackLoop)[0])
+
+	g.Sort()
+	require.Equal(t, unit.Sample, ax.Mode)
+	require.Equal(t, unit.Sample, bx.Mode)
+
+	_, err = PatchInput(b, map[string]interface{}{"x": 0.5}, false)(g)
+	require.NoError(t, err)
+	require.Empty(t, g.FeedbackLoops())
+	require.Empty(t, a.Out["out"].Out().Destinations())
+}
+
+func incrementUnit() *unit.Unit {
+	io := unit.NewIO("increment", frameSize)
+	inc := &increment{in: io.NewIn("x", dsp.Float64(0)), out: io.NewOut("out")}
+	return unit.NewUnit(io, inc)
+}
+
+type increment struct {
+	in  *unit.In
+	out *unit.Out
+}
+
+func (inc *increment) ProcessSample(i int) { inc.out.Write(i, inc.in.Read(i)+1) }
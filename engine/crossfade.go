@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"io"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+// crossfadeHold is the longest time (in milliseconds) a retired graph is held at full level while waiting for the
+// graph replacing it to produce a signal.
+const crossfadeHold = 2000
+
+// retiredGraph is a graph that has been replaced but continues to be processed while it's faded out.
+type retiredGraph struct {
+	processors  []unit.FrameProcessor
+	out         [][]float64
+	level, step float64
+	hold        int
+}
+
+// Crossfade replaces the graph with an empty one. The replaced graph keeps playing until the new graph produces a
+// signal; then the two are crossfaded over a duration (in milliseconds) and the replaced graph is closed.
+func (g *Graph) Crossfade(duration, frameSize, sampleRate int) error {
+	if err := g.closeRetired(); err != nil {
+		return err
+	}
+	g.retired = &retiredGraph{
+		processors: g.processors,
+		out:        g.out,
+		level:      1,
+		step:       1 / dsp.DurationInt(duration, sampleRate).Float64(),
+		hold:       int(dsp.DurationInt(crossfadeHold, sampleRate).Float64()),
+	}
+	g.processors = make([]unit.FrameProcessor, 0, cap(g.retired.processors))
+	return g.reset(duration, frameSize, sampleRate, true)
+}
+
+// processRetired processes a frame of the retired graph, if there is one. It's called once the current graph has
+// processed the frame, so the fade can begin in the same frame a signal is first detected.
+func (g *Graph) processRetired(n int) {
+	r := g.retired
+	if r == nil {
+		return
+	}
+	for _, p := range r.processors {
+		p.ProcessFrame(n)
+	}
+	if r.hold > 0 && g.sinkProcessor.hasSignal() {
+		r.hold = 0
+	}
+}
+
+// retiredSample returns a sample of a channel of the retired graph scaled by its current level.
+func (g *Graph) retiredSample(channel, i int) float64 {
+	r := g.retired
+	if r == nil {
+		return 0
+	}
+	level := r.level
+	if r.hold <= 0 {
+		level -= float64(i) * r.step
+	}
+	if level <= 0 {
+		return 0
+	}
+	return r.out[channel%len(r.out)][i] * level
+}
+
+// advanceRetired advances the fade of the retired graph by a frame; closing it once it's silent.
+func (g *Graph) advanceRetired(n int) error {
+	r := g.retired
+	if r == nil {
+		return nil
+	}
+	if r.hold > 0 {
+		r.hold -= n
+		return nil
+	}
+	r.level -= float64(n) * r.step
+	if r.level > 0 {
+		return nil
+	}
+	return g.closeRetired()
+}
+
+func (g *Graph) closeRetired() error {
+	if g.retired == nil {
+		return nil
+	}
+	processors := g.retired.processors
+	g.retired = nil
+	return closeProcessors(processors)
+}
+
+func closeProcessors(processors []unit.FrameProcessor) error {
+	for _, p := range processors {
+		if closer, ok := p.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
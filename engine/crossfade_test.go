@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestEngine_Crossfade(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	e, err := New(be, frameSize, WithCrossfade(10))
+	require.NoError(t, err)
+
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	)
+	emitConstant := func(v float64) {
+		u := constantUnit(v)
+		require.NoError(t, e.graph.Mount(u))
+		_, err := EmitOutputs(unit.OutRef{Unit: u, Output: "out"})(e.graph)
+		require.NoError(t, err)
+		e.graph.Sort()
+	}
+
+	emitConstant(1)
+	e.callback(in, out)
+	require.Equal(t, float32(1), out[0][frameSize-1])
+
+	// The old graph plays at full level until the new one produces a signal
+	_, err = Clear(e)
+	require.NoError(t, err)
+	e.callback(in, out)
+	require.NotNil(t, e.graph.retired)
+	require.Equal(t, float32(1), out[0][0])
+	require.Equal(t, float32(1), out[1][frameSize-1])
+
+	emitConstant(0.5)
+	e.callback(in, out)
+	for i := 1; i < frameSize; i++ {
+		require.True(t, out[0][i] < out[0][i-1], "output should be decreasing during the crossfade")
+		require.True(t, out[0][i] > 0.5)
+	}
+
+	// 10ms is 441 samples; the crossfade completes within two frames
+	e.callback(in, out)
+	e.callback(in, out)
+	require.Nil(t, e.graph.retired)
+	require.Equal(t, float32(0.5), out[0][frameSize-1])
+	require.Equal(t, float32(0.5), out[1][frameSize-1])
+}
+
+func TestEngine_CrossfadeBatchRollback(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	e, err := New(be, frameSize, WithCrossfade(10))
+	require.NoError(t, err)
+
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+		u   = constantUnit(1)
+	)
+	require.NoError(t, e.graph.Mount(u))
+	_, err = EmitOutputs(unit.OutRef{Unit: u, Output: "out"})(e.graph)
+	require.NoError(t, err)
+	e.graph.Sort()
+	e.callback(in, out)
+
+	// A failed Batch restores the cleared graph in place; nothing is faded out.
+	_, err = Batch(Clear, PatchInput(u, map[string]interface{}{"missing": 1.0}, false))(e)
+	require.Error(t, err)
+	require.Nil(t, e.graph.retired)
+	require.True(t, u.AttachedTo(e.graph.graph))
+	e.callback(in, out)
+	require.Equal(t, float32(1), out[0][frameSize-1])
+
+	_, err = Batch(Clear)(e)
+	require.NoError(t, err)
+	require.NotNil(t, e.graph.retired)
+	require.False(t, u.AttachedTo(e.graph.graph))
+	e.callback(in, out)
+	require.Equal(t, float32(1), out[0][frameSize-1])
+}
+
+func TestEngine_CrossfadeHold(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	e, err := New(be, frameSize, WithCrossfade(10))
+	require.NoError(t, err)
+
+	u := constantUnit(1)
+	require.NoError(t, e.graph.Mount(u))
+	_, err = EmitOutputs(unit.OutRef{Unit: u, Output: "out"})(e.graph)
+	require.NoError(t, err)
+	e.graph.Sort()
+
+	_, err = Clear(e)
+	require.NoError(t, err)
+
+	// Clearing to silence fades the old graph out once the hold has elapsed
+	var (
+		in     = [][]float32{make([]float32, frameSize)}
+		out    = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+		frames = (crossfadeHold+10)*sampleRate/1000/frameSize + 2
+	)
+	for i := 0; i < frames; i++ {
+		e.callback(in, out)
+	}
+	require.Nil(t, e.graph.retired)
+	require.Equal(t, float32(0), out[0][frameSize-1])
+}
+
+func constantUnit(v float64) *unit.Unit {
+	io := unit.NewIO("constant", frameSize)
+	return unit.NewUnit(io, &constant{io.NewOut("out"), v})
+}
+
+type constant struct {
+	out *unit.Out
+	v   float64
+}
+
+func (c *constant) ProcessSample(i int) { c.out.Write(i, c.v) }
@@ -3,19 +3,29 @@ package engine
 
 import (
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/brettbuddin/shaden/errors"
 	"github.com/brettbuddin/shaden/unit"
 )
 
-// Backend is a low-level callback-based engine
+// Backend is a low-level callback-based engine. The callback is given non-interleaved input and output channels.
 type Backend interface {
-	Start(func([]float32, [][]float32)) error
+	Start(func([][]float32, [][]float32)) error
 	Stop() error
 	SampleRate() int
 	FrameSize() int
 }
 
+const (
+	// defaultMessageLimit is the default maximum number of messages handled before each chunk is processed.
+	defaultMessageLimit = 64
+
+	// messageBudgetShare is the share of a chunk's real-time budget that may be spent handling messages.
+	messageBudgetShare = 0.25
+)
+
 // Option is an option for the Engine
 type Option func(*Engine)
 
@@ -26,6 +36,14 @@ func WithMessageChannel(ch MessageChannel) Option {
 	}
 }
 
+// WithMessageLimit sets the maximum number of messages handled before each chunk of audio is processed. Defaults to
+// 64. Messages are also only handled while time remains in the message budget of the chunk.
+func WithMessageLimit(n int) Option {
+	return func(e *Engine) {
+		e.messageLimit = n
+	}
+}
+
 // WithSingleSampleDisabled disables the single-sample feedback loop behavior.
 func WithSingleSampleDisabled() Option {
 	return func(e *Engine) {
@@ -33,6 +51,54 @@ func WithSingleSampleDisabled() Option {
 	}
 }
 
+// WithOutputChannels sets the number of output channels of the Engine. Channels are written to the backend in order; if
+// the backend provides more channels than this, the outputs are repeated across them.
+func WithOutputChannels(n int) Option {
+	return func(e *Engine) {
+		e.graph.outputChannels = n
+	}
+}
+
+// WithInputChannels sets the number of input channels of the Engine. These are exposed as outputs of the "source" Unit.
+func WithInputChannels(n int) Option {
+	return func(e *Engine) {
+		e.graph.inputChannels = n
+	}
+}
+
+// WithProfiling enables measurement of the time spent processing each Unit. Measurements are aggregated over windows
+// of a specific duration.
+func WithProfiling(window time.Duration) Option {
+	return func(e *Engine) {
+		e.graph.profiler = newProfiler(window)
+	}
+}
+
+// WithCrossfade crossfades between the current graph and its replacement over a duration (in milliseconds) when the
+// Engine is cleared, instead of cutting to silence.
+func WithCrossfade(ms int) Option {
+	return func(e *Engine) {
+		e.crossfade = ms
+	}
+}
+
+// WithWorkers sets the number of goroutines used to process independent parts of the graph concurrently. Defaults to
+// 1; processing the whole graph on the goroutine of the backend.
+func WithWorkers(n int) Option {
+	return func(e *Engine) {
+		e.workers = n
+	}
+}
+
+// WithBackgroundSort sorts the graph on a background goroutine once it has changed, rather than within the callback.
+// The previous graph keeps playing until the sort completes; so changes are heard a little later, and the timing of
+// when they're heard depends on how quickly the graph is sorted.
+func WithBackgroundSort() Option {
+	return func(e *Engine) {
+		e.backgroundSort = true
+	}
+}
+
 // WithFadeIn fades the engine output in to prevent pops
 func WithFadeIn(ms int) Option {
 	return func(e *Engine) {
@@ -40,6 +106,13 @@ func WithFadeIn(ms int) Option {
 	}
 }
 
+// WithSeed sets the random seed that Units created for the Engine derive their random sources from.
+func WithSeed(seed int64) Option {
+	return func(e *Engine) {
+		e.seed = seed
+	}
+}
+
 // WithGain sets the global gain for all samples written to the output
 func WithGain(gain float32) Option {
 	return func(e *Engine) {
@@ -49,33 +122,67 @@ func WithGain(gain float32) Option {
 
 // Engine is the connection of the synthesizer to PortAudio
 type Engine struct {
-	messages     MessageChannel
-	backend      Backend
-	graph        *Graph
-	errors, stop chan error
-	chunks       int
-	fadeIn       int
-	frameSize    int
-	gain         float32
+	messages       MessageChannel
+	messageLimit   int
+	messageBudget  time.Duration
+	backend        Backend
+	graph          *Graph
+	load           *loadMeter
+	errors, stop   chan error
+	chunks         int
+	fadeIn         int
+	crossfade      int
+	workers        int
+	frameSize      int
+	backgroundSort bool
+	gain           float32
+	seed           int64
+
+	// batching is set while a Batch is being applied.
+	batching bool
 }
 
 // New returns a new Sink
 func New(backend Backend, frameSize int, opts ...Option) (*Engine, error) {
 	e := &Engine{
 		backend:   backend,
-		messages:  newMessageChannel(),
+		messages:  newQueue(defaultQueueSize),
 		graph:     NewGraph(frameSize),
+		load:      newLoadMeter(backend.FrameSize(), backend.SampleRate()),
 		errors:    make(chan error),
 		stop:      make(chan error),
 		chunks:    int(backend.FrameSize() / frameSize),
 		frameSize: frameSize,
 		gain:      1,
+		workers:   1,
+
+		messageLimit:  defaultMessageLimit,
+		messageBudget: math.MaxInt64,
+	}
+	if sr := backend.SampleRate(); sr > 0 {
+		e.messageBudget = time.Duration(messageBudgetShare * float64(frameSize) / float64(sr) * float64(time.Second))
 	}
 
 	for _, opt := range opts {
 		opt(e)
 	}
 
+	if e.graph.outputChannels < 1 {
+		return nil, errors.Errorf("output channel count must be greater than zero")
+	}
+	if e.graph.inputChannels < 1 {
+		return nil, errors.Errorf("input channel count must be greater than zero")
+	}
+	if e.workers < 1 {
+		return nil, errors.Errorf("worker count must be greater than zero")
+	}
+	if e.workers > 1 {
+		e.graph.pool = newWorkerPool(e.workers)
+	}
+	if e.backgroundSort {
+		e.graph.sorter = newSorter(e.graph)
+	}
+
 	return e, e.graph.Reset(e.fadeIn, e.frameSize, backend.SampleRate())
 }
 
@@ -85,6 +192,31 @@ func (e *Engine) SampleRate() int { return e.backend.SampleRate() }
 // FrameSize returns the frame size
 func (e *Engine) FrameSize() int { return e.frameSize }
 
+// InputChannels returns the number of input channels
+func (e *Engine) InputChannels() int { return e.graph.inputChannels }
+
+// OutputChannels returns the number of output channels
+func (e *Engine) OutputChannels() int { return e.graph.outputChannels }
+
+// Stats returns measurements of the processing load of the Engine. It's safe to call from any goroutine.
+func (e *Engine) Stats() Stats { return e.load.snapshot() }
+
+// Profile returns the processing time spent in each Unit over the most recently completed profiling window. It's safe
+// to call from any goroutine.
+func (e *Engine) Profile() (Profile, error) {
+	if e.graph.profiler == nil {
+		return Profile{}, errors.New("profiling is not enabled")
+	}
+	p := e.graph.profiler.profile()
+	if p == nil {
+		return Profile{}, errors.New("no profiling window has completed yet")
+	}
+	return *p, nil
+}
+
+// Seed returns the random seed
+func (e *Engine) Seed() int64 { return e.seed }
+
 // UnitBuilders returns all unit.Builders for Units provided by the Engine.
 func (e *Engine) UnitBuilders() map[string]unit.Builder {
 	return unit.PrepareBuilders(map[string]unit.IOBuilder{
@@ -92,8 +224,12 @@ func (e *Engine) UnitBuilders() map[string]unit.Builder {
 	})
 }
 
-// Reset clears the state of the Engine. This includes clearing the audio graph.
+// Reset clears the state of the Engine. This includes clearing the audio graph. If crossfading is enabled, the current
+// graph is faded out as its replacement begins producing a signal.
 func (e *Engine) Reset() error {
+	if e.crossfade > 0 {
+		return e.graph.Crossfade(e.crossfade, e.frameSize, e.backend.SampleRate())
+	}
 	return e.graph.Reset(e.fadeIn, e.frameSize, e.backend.SampleRate())
 }
 
@@ -116,7 +252,14 @@ func (e *Engine) Run() {
 		e.stop <- err
 		return
 	}
-	e.stop <- e.backend.Stop()
+	err := e.backend.Stop()
+	if e.graph.pool != nil {
+		e.graph.pool.close()
+	}
+	if e.graph.sorter != nil {
+		e.graph.sorter.close()
+	}
+	e.stop <- err
 }
 
 // Stop shuts down the Engine
@@ -143,10 +286,6 @@ func (e *Engine) handle(msg *Message) {
 	start := time.Now()
 	data, err := e.call(msg.Action)
 
-	if err == nil {
-		e.graph.Sort()
-	}
-
 	if msg.Reply != nil {
 		msg.Reply <- &Reply{
 			Duration: time.Since(start),
@@ -156,35 +295,82 @@ func (e *Engine) handle(msg *Message) {
 	}
 }
 
+// handleMessages handles waiting messages until the message limit or the time budget for a chunk is reached. The
+// graph is sorted once all changes have been applied. When sorting in the background, messages are left waiting until
+// the previous sort has completed.
+func (e *Engine) handleMessages() {
+	if e.graph.sorter != nil {
+		ready, err := e.graph.receiveSorted()
+		if err != nil {
+			e.report(err)
+		}
+		if !ready {
+			return
+		}
+	}
+
+	start := time.Now()
+	for i := 0; i < e.messageLimit; i++ {
+		if i > 0 && time.Since(start) > e.messageBudget {
+			break
+		}
+		msg := e.messages.Receive()
+		if msg == nil {
+			break
+		}
+		e.handle(msg)
+	}
+	if e.graph.sorter != nil {
+		e.graph.sortInBackground()
+		return
+	}
+	e.graph.Sort()
+}
+
+// report sends an error to the Errors channel without blocking the audio thread.
+func (e *Engine) report(err error) {
+	select {
+	case e.errors <- err:
+	default:
+	}
+}
+
 // callback is the callback function provided to PortAudio; it drives the entire synthesiser.
-func (e *Engine) callback(in []float32, out [][]float32) {
-	for k := 0; k < e.chunks; k++ {
-		if msg := e.messages.Receive(); msg != nil {
-			e.handle(msg)
+func (e *Engine) callback(in, out [][]float32) {
+	start := time.Now()
+	defer func() {
+		e.load.record(time.Since(start))
+		if e.graph.profiler != nil {
+			e.graph.profiler.tick()
 		}
+	}()
+
+	for k := 0; k < e.chunks; k++ {
+		e.handleMessages()
+		e.graph.advanceMorph(e.frameSize)
 
 		var (
 			frameSize = e.frameSize
 			offset    = frameSize * k
-			input     = e.graph.in
-			leftOut   = e.graph.leftOut
-			rightOut  = e.graph.rightOut
+			inputs    = e.graph.in
+			outputs   = e.graph.out
 			gain      = e.gain
 		)
-		for i := 0; i < frameSize; i++ {
-			input[i] = float64(in[offset+i])
-		}
-		for _, p := range e.graph.Processors() {
-			p.ProcessFrame(frameSize)
+		for i := 0; i < len(in) && i < len(inputs); i++ {
+			for j := 0; j < frameSize; j++ {
+				inputs[i][j] = float64(in[i][offset+j])
+			}
 		}
+		e.graph.process(frameSize)
+		e.graph.processRetired(frameSize)
 		for i := range out {
+			output := outputs[i%len(outputs)]
 			for j := 0; j < frameSize; j++ {
-				if i%2 == 0 {
-					out[i][offset+j] = float32(leftOut[j]) * gain
-				} else {
-					out[i][offset+j] = float32(rightOut[j]) * gain
-				}
+				out[i][offset+j] = float32(output[j]+e.graph.retiredSample(i, j)) * gain
 			}
 		}
+		if err := e.graph.advanceRetired(frameSize); err != nil {
+			e.report(err)
+		}
 	}
 }
@@ -16,7 +16,7 @@ const (
 
 func TestEngine_Stop(t *testing.T) {
 	be := backend{
-		start:     func(func([]float32, [][]float32)) error { return nil },
+		start:     func(func([][]float32, [][]float32)) error { return nil },
 		stop:      func() error { return nil },
 		frameSize: frameSize * 2,
 	}
@@ -32,7 +32,7 @@ func TestEngine_Stop(t *testing.T) {
 
 func TestEngine_StopProxyBackendError(t *testing.T) {
 	be := backend{
-		start:     func(func([]float32, [][]float32)) error { return nil },
+		start:     func(func([][]float32, [][]float32)) error { return nil },
 		stop:      func() error { return fmt.Errorf("exploded") },
 		frameSize: frameSize * 2,
 	}
@@ -48,7 +48,7 @@ func TestEngine_StopProxyBackendError(t *testing.T) {
 
 func TestEngine_StartError(t *testing.T) {
 	be := backend{
-		start:     func(func([]float32, [][]float32)) error { return fmt.Errorf("exploded") },
+		start:     func(func([][]float32, [][]float32)) error { return fmt.Errorf("exploded") },
 		stop:      func() error { return nil },
 		frameSize: frameSize * 2,
 	}
@@ -70,20 +70,20 @@ func TestEngine_MountAndUnmount(t *testing.T) {
 	size := frameSize * 2
 
 	be := backend{
-		start: func(cb func([]float32, [][]float32)) error {
+		start: func(cb func([][]float32, [][]float32)) error {
 			out := make([][]float32, 2)
 			for i := 0; i < size; i++ {
 				out[0] = make([]float32, size)
 				out[1] = make([]float32, size)
 			}
-			cb(make([]float32, size), out) // receive mount message
-			cb(make([]float32, size), out) // receive unmount message
+			// Each chunk of the callback receives a message: mount, then unmount
+			cb([][]float32{make([]float32, size)}, out)
 			return nil
 		},
 		stop:      func() error { return nil },
 		frameSize: size,
 	}
-	e, err := New(be, frameSize)
+	e, err := New(be, frameSize, WithMessageChannel(newBlockingMessages()), WithMessageLimit(1))
 	require.NoError(t, err)
 	require.Equal(t, 3, e.graph.Size())
 
@@ -135,20 +135,20 @@ func TestEngine_MountAndReset(t *testing.T) {
 	size := frameSize * 2
 
 	be := backend{
-		start: func(cb func([]float32, [][]float32)) error {
+		start: func(cb func([][]float32, [][]float32)) error {
 			out := make([][]float32, 2)
 			for i := 0; i < size; i++ {
 				out[0] = make([]float32, size)
 				out[1] = make([]float32, size)
 			}
-			cb(make([]float32, size), out)
-			cb(make([]float32, size), out)
+			// Each chunk of the callback receives a message: mount, then clear
+			cb([][]float32{make([]float32, size)}, out)
 			return nil
 		},
 		stop:      func() error { return nil },
 		frameSize: size,
 	}
-	e, err := New(be, frameSize)
+	e, err := New(be, frameSize, WithMessageChannel(newBlockingMessages()), WithMessageLimit(1))
 	require.NoError(t, err)
 	require.Equal(t, 3, e.graph.Size())
 
@@ -191,13 +191,106 @@ func TestEngine_MountAndReset(t *testing.T) {
 	require.NoError(t, e.Stop())
 }
 
+func TestEngine_OutputChannels(t *testing.T) {
+	be := backend{
+		start:     func(func([][]float32, [][]float32)) error { return nil },
+		stop:      func() error { return nil },
+		frameSize: frameSize,
+	}
+	e, err := New(be, frameSize, WithOutputChannels(8))
+	require.NoError(t, err)
+	require.Equal(t, 8, e.OutputChannels())
+	require.Len(t, e.graph.sink.In, 8)
+
+	_, err = New(be, frameSize, WithOutputChannels(0))
+	require.Error(t, err)
+}
+
+func TestEngine_InputChannels(t *testing.T) {
+	be := backend{
+		start:     func(func([][]float32, [][]float32)) error { return nil },
+		stop:      func() error { return nil },
+		frameSize: frameSize,
+	}
+	e, err := New(be, frameSize, WithInputChannels(2))
+	require.NoError(t, err)
+	require.Equal(t, 2, e.InputChannels())
+
+	source, err := e.UnitBuilders()["source"](unit.Config{FrameSize: frameSize})
+	require.NoError(t, err)
+	require.Len(t, source.Out, 3)
+
+	in := [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	for i := 0; i < frameSize; i++ {
+		in[0][i] = 0.25
+		in[1][i] = -0.5
+	}
+	out := [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	e.callback(in, out)
+
+	require.Equal(t, 0.25, source.Out["0"].Out().Read(0))
+	require.Equal(t, 0.25, source.Out["output"].Out().Read(0))
+	require.Equal(t, -0.5, source.Out["1"].Out().Read(frameSize-1))
+	require.Equal(t, uint64(1), e.Stats().Callbacks)
+
+	_, err = New(be, frameSize, WithInputChannels(0))
+	require.Error(t, err)
+}
+
+func TestEngine_HandlesMessagesInBatches(t *testing.T) {
+	be := backend{
+		start:     func(func([][]float32, [][]float32)) error { return nil },
+		stop:      func() error { return nil },
+		frameSize: frameSize,
+	}
+	e, err := New(be, frameSize, WithMessageLimit(3))
+	require.NoError(t, err)
+
+	msgs := make([]*Message, 4)
+	for i := range msgs {
+		msgs[i] = NewMessage(MountUnit(unit.NewUnit(unit.NewIO("example", frameSize), nil)))
+		require.NoError(t, e.SendMessage(msgs[i]))
+	}
+
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	)
+
+	// The message limit is reached after three messages
+	e.callback(in, out)
+	require.Equal(t, 6, e.graph.Size())
+	for _, msg := range msgs[:3] {
+		require.NoError(t, (<-msg.Reply).Error)
+	}
+	require.Len(t, msgs[3].Reply, 0)
+
+	e.callback(in, out)
+	require.Equal(t, 7, e.graph.Size())
+	require.NoError(t, (<-msgs[3].Reply).Error)
+}
+
 type backend struct {
-	start                 func(func([]float32, [][]float32)) error
+	start                 func(func([][]float32, [][]float32)) error
 	stop                  func() error
 	sampleRate, frameSize int
 }
 
-func (b backend) Start(cb func([]float32, [][]float32)) error { return b.start(cb) }
-func (b backend) Stop() error                                 { return b.stop() }
-func (b backend) FrameSize() int                              { return b.frameSize }
-func (b backend) SampleRate() int                             { return b.sampleRate }
+func (b backend) Start(cb func([][]float32, [][]float32)) error { return b.start(cb) }
+func (b backend) Stop() error                                   { return b.stop() }
+func (b backend) FrameSize() int                                { return b.frameSize }
+func (b backend) SampleRate() int                               { return b.sampleRate }
+
+func newBlockingMessages() blockingMessages {
+	return blockingMessages{make(chan *Message)}
+}
+
+// blockingMessages blocks the audio thread until a message is sent; allowing tests to step the engine one message at
+// a time.
+type blockingMessages struct {
+	messages chan *Message
+}
+
+func (c blockingMessages) Receive() *Message       { return <-c.messages }
+func (c blockingMessages) Send(msg *Message) error { c.messages <- msg; return nil }
+func (c blockingMessages) Close()                  { close(c.messages) }
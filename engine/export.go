@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+// GraphDescription is a snapshot of the units in the graph and the connections between them.
+type GraphDescription struct {
+	Units       []UnitDescription `json:"units"`
+	Connections []Connection      `json:"connections"`
+}
+
+// UnitDescription describes a unit and its inputs and outputs. Config holds the values the unit was built with and
+// Props the values of its properties; neither are encoded as JSON, since their values may be of any type.
+type UnitDescription struct {
+	ID      string                 `json:"id"`
+	Type    string                 `json:"type"`
+	Inputs  []InputDescription     `json:"inputs"`
+	Outputs []string               `json:"outputs"`
+	Config  map[string]interface{} `json:"-"`
+	Props   map[string]interface{} `json:"-"`
+}
+
+// InputDescription describes an input of a unit. Inputs without an inbound connection report their constant value.
+// Constant retains the value as it was patched (a frequency in Hz, for instance) and Normal reports whether it's the
+// value the input takes when nothing is patched into it.
+type InputDescription struct {
+	Name     string     `json:"name"`
+	Value    *float64   `json:"value,omitempty"`
+	Constant dsp.Valuer `json:"-"`
+	Normal   bool       `json:"-"`
+}
+
+// Connection is a connection from the output of one unit to the input of another. Delayed connections reach the input
+// a block late.
+type Connection struct {
+	From    string `json:"from"`
+	Output  string `json:"output"`
+	To      string `json:"to"`
+	Input   string `json:"input"`
+	Delayed bool   `json:"delayed,omitempty"`
+}
+
+// DescribeGraph describes the units of the graph and how they're connected. Units are listed in the order they were
+// mounted.
+func DescribeGraph(g *Graph) (interface{}, error) {
+	d := GraphDescription{
+		Units:       []UnitDescription{},
+		Connections: []Connection{},
+	}
+	for _, n := range g.graph.Nodes() {
+		u, ok := n.Value.(*unit.Unit)
+		if !ok {
+			continue
+		}
+		ud := UnitDescription{
+			ID:      u.ID,
+			Type:    u.Type,
+			Config:  u.Config,
+			Inputs:  make([]InputDescription, 0, len(u.In)),
+			Outputs: make([]string, 0, len(u.Out)),
+		}
+		names := make([]string, 0, len(u.In))
+		for k := range u.In {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			in := u.In[name]
+			id := InputDescription{Name: name}
+			if c := in.Constant(); c != nil {
+				v := c.Float64()
+				id.Value = &v
+				id.Constant = c
+				id.Normal = v == in.Normal().Float64()
+			}
+			if src := in.Source(); src != nil {
+				d.Connections = append(d.Connections, Connection{
+					From:    src.Unit().ID,
+					Output:  src.Name,
+					To:      u.ID,
+					Input:   name,
+					Delayed: in.Delayed(),
+				})
+			}
+			ud.Inputs = append(ud.Inputs, id)
+		}
+		if len(u.Prop) > 0 {
+			ud.Props = make(map[string]interface{}, len(u.Prop))
+			for k, p := range u.Prop {
+				ud.Props[k] = p.Value()
+			}
+		}
+		for k := range u.Out {
+			ud.Outputs = append(ud.Outputs, k)
+		}
+		sort.Strings(ud.Outputs)
+		d.Units = append(d.Units, ud)
+	}
+	return d, nil
+}
+
+// WriteJSON writes the description as a JSON document.
+func (d GraphDescription) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteDOT writes the description as a Graphviz DOT digraph. Each unit is a record node with its inputs on the left
+// and its outputs on the right. Delayed connections are dashed.
+func (d GraphDescription) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph shaden {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=record];")
+	for _, u := range d.Units {
+		inputs := make([]string, len(u.Inputs))
+		for i, in := range u.Inputs {
+			label := dotEscape(in.Name)
+			if in.Value != nil {
+				label += ": " + dotEscape(strconv.FormatFloat(*in.Value, 'g', 6, 64))
+			}
+			inputs[i] = fmt.Sprintf("<in-%s> %s", dotEscape(in.Name), label)
+		}
+		outputs := make([]string, len(u.Outputs))
+		for i, out := range u.Outputs {
+			outputs[i] = fmt.Sprintf("<out-%s> %s", dotEscape(out), dotEscape(out))
+		}
+		fmt.Fprintf(bw, "\t%q [label=\"{{%s}|%s|{%s}}\"];\n",
+			u.ID,
+			strings.Join(inputs, "|"),
+			dotEscape(u.ID),
+			strings.Join(outputs, "|"))
+	}
+	for _, c := range d.Connections {
+		var attrs string
+		if c.Delayed {
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(bw, "\t%q:%q -> %q:%q%s;\n", c.From, "out-"+c.Output, c.To, "in-"+c.Input, attrs)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotEscape escapes characters that have meaning within DOT record labels.
+func dotEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '{', '}', '|', '<', '>', '"', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
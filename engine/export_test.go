@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestDescribeGraph(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.createSink(100, frameSize, sampleRate, false))
+
+	io1 := unit.NewIO("osc", frameSize)
+	io1.NewIn("freq", dsp.Float64(0.5))
+	io1.NewOut("out")
+	osc := unit.NewUnit(io1, nil)
+	require.NoError(t, g.Mount(osc))
+
+	io2 := unit.NewIO("vca", frameSize)
+	io2.NewIn("in", dsp.Float64(0))
+	io2.NewIn("level", dsp.Float64(1))
+	io2.NewOut("out")
+	vca := unit.NewUnit(io2, nil)
+	require.NoError(t, g.Mount(vca))
+
+	_, err := PatchInput(vca, map[string]interface{}{
+		"in":    unit.OutRef{Unit: osc, Output: "out"},
+		"level": 0.25,
+	}, false)(g)
+	require.NoError(t, err)
+	_, err = EmitOutputs(unit.OutRef{Unit: vca, Output: "out"})(g)
+	require.NoError(t, err)
+
+	v, err := DescribeGraph(g)
+	require.NoError(t, err)
+	d := v.(GraphDescription)
+
+	require.Len(t, d.Units, 3)
+	require.Equal(t, g.sink.ID, d.Units[0].ID)
+	require.Equal(t, osc.ID, d.Units[1].ID)
+	require.Equal(t, "osc", d.Units[1].Type)
+	require.Equal(t, []string{"out"}, d.Units[1].Outputs)
+
+	inputs := d.Units[2].Inputs
+	require.Len(t, inputs, 2)
+	require.Equal(t, "in", inputs[0].Name)
+	require.Nil(t, inputs[0].Value)
+	require.Equal(t, "level", inputs[1].Name)
+	require.Equal(t, 0.25, *inputs[1].Value)
+	require.False(t, inputs[1].Normal)
+	require.True(t, d.Units[1].Inputs[0].Normal)
+
+	require.Equal(t, []Connection{
+		{From: vca.ID, Output: "out", To: g.sink.ID, Input: "0"},
+		{From: vca.ID, Output: "out", To: g.sink.ID, Input: "1"},
+		{From: osc.ID, Output: "out", To: vca.ID, Input: "in"},
+	}, d.Connections)
+
+	var buf bytes.Buffer
+	require.NoError(t, d.WriteJSON(&buf))
+	var decoded GraphDescription
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, d.Connections, decoded.Connections)
+	require.Equal(t, 0.25, *decoded.Units[2].Inputs[1].Value)
+
+	buf.Reset()
+	require.NoError(t, d.WriteDOT(&buf))
+	dot := buf.String()
+	require.Contains(t, dot, "digraph shaden {")
+	require.Contains(t, dot, fmt.Sprintf(`%q [label="{{<in-in> in|<in-level> level: 0.25}|%s|{<out-out> out}}"];`, vca.ID, vca.ID))
+	require.Contains(t, dot, fmt.Sprintf(`%q:"out-out" -> %q:"in-in";`, osc.ID, vca.ID))
+}
+
+func TestDotEscape(t *testing.T) {
+	require.Equal(t, `a \{b\|c\}\<d\>`, dotEscape("a {b|c}<d>"))
+}
+
+func TestDescribeGraph_Delayed(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.createSink(100, frameSize, sampleRate, false))
+
+	u := inputUnit("x")
+	require.NoError(t, g.Mount(u))
+	_, err := PatchInput(u, map[string]interface{}{
+		"x": unit.FeedbackRef{OutRef: unit.OutRef{Unit: u, Output: "out"}},
+	}, false)(g)
+	require.NoError(t, err)
+
+	v, err := DescribeGraph(g)
+	require.NoError(t, err)
+	d := v.(GraphDescription)
+	require.Equal(t, []Connection{
+		{From: u.ID, Output: "out", To: u.ID, Input: "x", Delayed: true},
+	}, d.Connections)
+
+	var buf bytes.Buffer
+	require.NoError(t, d.WriteDOT(&buf))
+	require.Contains(t, buf.String(), fmt.Sprintf(`%q:"out-out" -> %q:"in-x" [style=dashed];`, u.ID, u.ID))
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/brettbuddin/shaden/dsp"
 	"github.com/brettbuddin/shaden/errors"
@@ -13,21 +14,29 @@ import (
 // NewGraph returns a new Graph.
 func NewGraph(frameSize int) *Graph {
 	return &Graph{
-		graph:      graph.New(),
-		processors: make([]unit.FrameProcessor, 100),
-		in:         make([]float64, frameSize),
-		leftOut:    make([]float64, frameSize),
-		rightOut:   make([]float64, frameSize),
+		graph:          graph.New(),
+		processors:     make([]unit.FrameProcessor, 100),
+		inputChannels:  1,
+		outputChannels: 2,
 	}
 }
 
 // Graph is a graph of units.
 type Graph struct {
-	singleSampleDisabled  bool
-	graph                 *graph.Graph
-	processors            []unit.FrameProcessor
-	sink                  *unit.Unit
-	in, leftOut, rightOut []float64
+	singleSampleDisabled          bool
+	profiler                      *profiler
+	pool                          *workerPool
+	sorter                        *sorter
+	inputChannels, outputChannels int
+	graph                         *graph.Graph
+	processors                    []unit.FrameProcessor
+	levels                        [][]unit.FrameProcessor
+	sink                          *unit.Unit
+	sinkProcessor                 *sink
+	retired                       *retiredGraph
+	morph                         *morph
+	unmounted, detached           []*unit.Unit
+	in, out                       [][]float64
 }
 
 // Processors returns the sorted slice of unit.FrameProcessors.
@@ -38,12 +47,89 @@ func (g *Graph) Sort() {
 	if !g.graph.HasChanged() {
 		return
 	}
-	processors := g.processors[:0]
-	for _, v := range g.graph.Sorted() {
-		collectProcessor(&processors, v, g.singleSampleDisabled)
-	}
-	g.processors = processors
 	g.graph.AckChange()
+	g.install(g.schedule())
+}
+
+// schedule is the order processors are processed in. It isn't modified once it's been built; so it can be built away
+// from the audio thread.
+type schedule struct {
+	processors []unit.FrameProcessor
+	levels     [][]unit.FrameProcessor
+	modes      []inputMode
+	counters   map[string]*profileCounter
+}
+
+// inputMode is the mode of processing an input switches to once a schedule is installed.
+type inputMode struct {
+	in   *unit.In
+	mode unit.InMode
+}
+
+// schedule builds a schedule from the sorted graph. It reads the graph, but doesn't modify anything the audio thread
+// uses.
+func (g *Graph) schedule() *schedule {
+	var (
+		s      = &schedule{}
+		sorted = g.graph.Sorted()
+		levels []int
+	)
+	if g.pool == nil {
+		for _, v := range sorted {
+			collectProcessor(&s.processors, &s.modes, v, g.singleSampleDisabled)
+		}
+	} else {
+		levels = levelsOf(sorted, func(nodes []*graph.Node) int {
+			n := len(s.processors)
+			collectProcessor(&s.processors, &s.modes, nodes, g.singleSampleDisabled)
+			return len(s.processors) - n
+		})
+	}
+	if g.profiler != nil {
+		s.processors, s.counters = g.profiler.wrap(s.processors)
+	}
+	if levels != nil {
+		s.levels = groupLevels(s.processors, levels)
+	}
+	return s
+}
+
+// install switches processing over to a schedule.
+func (g *Graph) install(s *schedule) {
+	for _, m := range s.modes {
+		m.in.Mode = m.mode
+	}
+	g.processors = s.processors
+	g.levels = s.levels
+	if g.profiler != nil {
+		g.profiler.counters = s.counters
+	}
+}
+
+// groupLevels groups the sorted processors by the level they can be processed concurrently within.
+func groupLevels(processors []unit.FrameProcessor, levels []int) [][]unit.FrameProcessor {
+	var grouped [][]unit.FrameProcessor
+	for i, l := range levels {
+		for l >= len(grouped) {
+			grouped = append(grouped, nil)
+		}
+		grouped[l] = append(grouped[l], processors[i])
+	}
+	return grouped
+}
+
+// process processes a frame of every processor in the graph. If the graph has a pool of workers, independent
+// processors are processed concurrently; one level at a time.
+func (g *Graph) process(n int) {
+	if g.pool == nil {
+		for _, p := range g.processors {
+			p.ProcessFrame(n)
+		}
+		return
+	}
+	for _, level := range g.levels {
+		g.pool.process(level, n)
+	}
 }
 
 // Reset empties the graph.
@@ -51,9 +137,22 @@ func (g *Graph) Reset(fadeIn, frameSize, sampleRate int) error {
 	if err := g.Close(); err != nil {
 		return err
 	}
+	return g.reset(fadeIn, frameSize, sampleRate, false)
+}
+
+// reset replaces the graph with an empty one. If deferFadeIn is set the fade-in of the output begins once a signal is
+// detected.
+func (g *Graph) reset(fadeIn, frameSize, sampleRate int, deferFadeIn bool) error {
 	g.graph = graph.New()
+	g.morph = nil
 
-	if err := g.createSink(fadeIn, frameSize, sampleRate); err != nil {
+	if len(g.in) != g.inputChannels {
+		g.in = make([][]float64, g.inputChannels)
+		for i := range g.in {
+			g.in[i] = make([]float64, frameSize)
+		}
+	}
+	if err := g.createSink(fadeIn, frameSize, sampleRate, deferFadeIn); err != nil {
 		return err
 	}
 	g.Sort()
@@ -61,38 +160,142 @@ func (g *Graph) Reset(fadeIn, frameSize, sampleRate int) error {
 	return nil
 }
 
-func (g *Graph) createSink(fadeIn, frameSize, sampleRate int) error {
+// replacedGraph is a graph that has been replaced by an empty one within a Batch. Nothing it holds is closed until the
+// Batch has been applied; so it can be restored if one of the Batch's actions fails.
+type replacedGraph struct {
+	graph               *graph.Graph
+	sink                *unit.Unit
+	sinkProcessor       *sink
+	out                 [][]float64
+	processors          []unit.FrameProcessor
+	levels              [][]unit.FrameProcessor
+	counters            map[string]*profileCounter
+	retired             *retiredGraph
+	morph               *morph
+	unmounted, detached []*unit.Unit
+	crossfade           bool
+}
+
+// replace replaces the graph with an empty one, as Reset does (or Crossfade, if the duration of the crossfade is
+// greater than zero), but keeps the replaced graph open.
+func (g *Graph) replace(crossfade, fadeIn, frameSize, sampleRate int) (*replacedGraph, error) {
+	r := &replacedGraph{
+		graph:         g.graph,
+		sink:          g.sink,
+		sinkProcessor: g.sinkProcessor,
+		out:           g.out,
+		processors:    g.processors,
+		levels:        g.levels,
+		retired:       g.retired,
+		morph:         g.morph,
+		unmounted:     g.unmounted,
+		detached:      g.detached,
+		crossfade:     crossfade > 0,
+	}
+	if g.profiler != nil {
+		r.counters = g.profiler.counters
+	}
+	g.retired, g.unmounted, g.detached = nil, nil, nil
+	if !r.crossfade {
+		return r, g.reset(fadeIn, frameSize, sampleRate, false)
+	}
+	g.retired = &retiredGraph{
+		processors: r.processors,
+		out:        r.out,
+		level:      1,
+		step:       1 / dsp.DurationInt(crossfade, sampleRate).Float64(),
+		hold:       int(dsp.DurationInt(crossfadeHold, sampleRate).Float64()),
+	}
+	g.processors = make([]unit.FrameProcessor, 0, cap(r.processors))
+	return r, g.reset(crossfade, frameSize, sampleRate, true)
+}
+
+// settle closes what Reset or Crossfade would have closed when the graph was replaced.
+func (g *Graph) settle(r *replacedGraph) error {
+	if r.crossfade {
+		g.unmounted = append(g.unmounted, r.unmounted...)
+		g.detached = append(g.detached, r.detached...)
+		return (&Graph{retired: r.retired}).closeRetired()
+	}
+	replaced := &Graph{
+		graph:      r.graph,
+		processors: r.processors,
+		retired:    r.retired,
+		unmounted:  r.unmounted,
+		detached:   r.detached,
+	}
+	return replaced.Close()
+}
+
+// restore puts a replaced graph back in place of its replacement. The Units detached or unmounted from the replacement
+// are kept; so they're still closed.
+func (g *Graph) restore(r *replacedGraph) {
+	g.graph = r.graph
+	g.sink = r.sink
+	g.sinkProcessor = r.sinkProcessor
+	g.out = r.out
+	g.processors = r.processors
+	g.levels = r.levels
+	g.retired = r.retired
+	g.morph = r.morph
+	g.unmounted = append(r.unmounted, g.unmounted...)
+	g.detached = append(r.detached, g.detached...)
+	if g.profiler != nil {
+		g.profiler.counters = r.counters
+	}
+}
+
+func (g *Graph) createSink(fadeIn, frameSize, sampleRate int, deferFadeIn bool) error {
 	var (
 		io       = unit.NewIO("sink", frameSize)
-		sink     = newSink(io, fadeIn, sampleRate, frameSize)
+		sink     = newSink(io, g.outputChannels, fadeIn, sampleRate, frameSize)
 		sinkUnit = unit.NewUnit(io, sink)
 	)
+	sink.deferFadeIn = deferFadeIn
 	if err := sinkUnit.Attach(g.graph); err != nil {
 		return err
 	}
 	g.sink = sinkUnit
-	g.leftOut = sink.left.out
-	g.rightOut = sink.right.out
+	g.sinkProcessor = sink
+	g.out = make([][]float64, len(sink.channels))
+	for i, c := range sink.channels {
+		g.out[i] = c.out
+	}
 	return nil
 }
 
+// sourceIOBuilder builds Units that expose each input channel as an output named by its index. The first channel is
+// also available as "output".
 func (g *Graph) sourceIOBuilder() unit.IOBuilder {
 	return func(io *unit.IO, _ unit.Config) (*unit.Unit, error) {
-		io.NewOutWithFrame("output", g.in)
+		for i, in := range g.in {
+			io.NewOutWithFrame(strconv.Itoa(i), in)
+		}
+		io.NewOutWithFrame("output", g.in[0])
 		return unit.NewUnit(io, nil), nil
 	}
 }
 
-// Close closes all processors in the graph.
+// Close closes all processors in the graph, along with those of a graph being faded out, the units waiting to be
+// closed once they're no longer processed and the units that have been detached.
 func (g *Graph) Close() error {
-	for _, p := range g.processors {
-		if closer, ok := p.(io.Closer); ok {
-			if err := closer.Close(); err != nil {
-				return err
-			}
+	if err := g.closeRetired(); err != nil {
+		return err
+	}
+	if err := g.closeUnmounted(); err != nil {
+		return err
+	}
+	detached := g.detached
+	g.detached = nil
+	for _, u := range detached {
+		if u.AttachedTo(g.graph) {
+			continue
+		}
+		if err := u.Close(); err != nil {
+			return err
 		}
 	}
-	return nil
+	return closeProcessors(g.processors)
 }
 
 // Patch patches a value into an input.
@@ -121,18 +324,58 @@ func (g *Graph) Patch(v interface{}, in *unit.In) error {
 		if err := unit.Patch(g.graph, out, in); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("patch %q into %q", out.Out(), in))
 		}
+	case unit.FeedbackRef:
+		out, ok := v.Unit.Out[v.Output]
+		if !ok {
+			return errors.Errorf("unit %q has no output %q", v.Unit.ID, v.Output)
+		}
+		if err := unit.PatchFeedback(g.graph, out, in); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("patch %q into %q with feedback", out.Out(), in))
+		}
 	}
 	return nil
 }
 
+// FeedbackLoops returns the units of each feedback loop in the graph. The units along a feedback loop are processed a
+// sample at a time.
+func (g *Graph) FeedbackLoops() []FeedbackLoop {
+	var loops []FeedbackLoop
+	for _, nodes := range g.graph.Sorted() {
+		if len(nodes) > 1 {
+			loops = append(loops, unit.UnitsOf(nodes))
+		}
+	}
+	return loops
+}
+
 // Mount adds a unit to the graph.
 func (g *Graph) Mount(u *unit.Unit) error { return u.Attach(g.graph) }
 
-// Unmount removes a unit from the graph.
+// Unmount removes a unit from the graph. If the graph is sorted in the background, the unit is only closed once the
+// schedule that no longer includes it is installed.
 func (g *Graph) Unmount(u *unit.Unit) error {
-	if err := u.Close(); err != nil {
-		return err
+	if g.sorter == nil {
+		if err := u.Close(); err != nil {
+			return err
+		}
+	}
+	if err := u.Detach(g.graph); err != nil {
+		switch err := err.(type) {
+		case graph.NotInGraphError:
+			return errors.Errorf("unit %q not in graph", u.ID)
+		default:
+			return err
+		}
 	}
+	if g.sorter != nil {
+		g.unmounted = append(g.unmounted, u)
+	}
+	return nil
+}
+
+// Detach removes a unit from the graph without closing it; so it can be mounted again. The unit is closed by
+// CloseDetached, or when the graph is closed.
+func (g *Graph) Detach(u *unit.Unit) error {
 	if err := u.Detach(g.graph); err != nil {
 		switch err := err.(type) {
 		case graph.NotInGraphError:
@@ -141,21 +384,69 @@ func (g *Graph) Unmount(u *unit.Unit) error {
 			return err
 		}
 	}
+	for _, d := range g.detached {
+		if d == u {
+			return nil
+		}
+	}
+	g.detached = append(g.detached, u)
+	return nil
+}
+
+// CloseDetached closes units removed from the graph by Detach; unless they've been mounted again. If the graph is
+// sorted in the background, the units are only closed once the schedule that no longer includes them is installed.
+func (g *Graph) CloseDetached(units ...*unit.Unit) error {
+	for _, u := range units {
+		for i, d := range g.detached {
+			if d == u {
+				g.detached = append(g.detached[:i], g.detached[i+1:]...)
+				break
+			}
+		}
+		if u.AttachedTo(g.graph) {
+			continue
+		}
+		if g.sorter != nil {
+			g.unmounted = append(g.unmounted, u)
+			continue
+		}
+		if err := u.Close(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// closeUnmounted closes the units unmounted since the last schedule was installed; unless they've been mounted again.
+func (g *Graph) closeUnmounted() error {
+	unmounted := g.unmounted
+	g.unmounted = nil
+	for _, u := range unmounted {
+		if u.AttachedTo(g.graph) {
+			continue
+		}
+		if err := u.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutputChannels returns the number of output channels of the sink.
+func (g *Graph) OutputChannels() int { return g.outputChannels }
+
 // Size returns the number of units in the graph.
 func (g *Graph) Size() int { return g.graph.Size() }
 
-func collectProcessor(processors *[]unit.FrameProcessor, nodes []*graph.Node, singleSampleDisabled bool) {
+func collectProcessor(processors *[]unit.FrameProcessor, modes *[]inputMode, nodes []*graph.Node, singleSampleDisabled bool) {
 	if len(nodes) > 1 {
-		collectGroup(processors, nodes, singleSampleDisabled)
+		collectGroup(processors, modes, nodes, singleSampleDisabled)
 		return
 	}
 
 	first := nodes[0]
 	if in, ok := first.Value.(*unit.In); ok && !singleSampleDisabled {
-		in.Mode = unit.Block
+		*modes = append(*modes, inputMode{in, unit.Block})
 	}
 	if p, ok := first.Value.(unit.FrameProcessor); ok {
 		if isp, ok := p.(unit.CondProcessor); ok {
@@ -168,11 +459,11 @@ func collectProcessor(processors *[]unit.FrameProcessor, nodes []*graph.Node, si
 	}
 }
 
-func collectGroup(processors *[]unit.FrameProcessor, nodes []*graph.Node, singleSampleDisabled bool) {
+func collectGroup(processors *[]unit.FrameProcessor, modes *[]inputMode, nodes []*graph.Node, singleSampleDisabled bool) {
 	var g group
 	for _, w := range nodes {
 		if in, ok := w.Value.(*unit.In); ok && !singleSampleDisabled {
-			in.Mode = unit.Sample
+			*modes = append(*modes, inputMode{in, unit.Sample})
 		}
 		if p, ok := w.Value.(unit.SampleProcessor); ok {
 			if isp, ok := p.(unit.CondProcessor); ok {
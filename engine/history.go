@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/errors"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+// Change is the result of an Undoable action. It holds the state of the Units the action affected from before and
+// after it was applied.
+type Change struct {
+	Data          interface{}
+	before, after []unitState
+}
+
+// Undo returns an action that restores the state from before the change.
+func (c Change) Undo() func(*Graph) (interface{}, error) { return restoreUnits(c.before) }
+
+// Redo returns an action that restores the state from after the change.
+func (c Change) Redo() func(*Graph) (interface{}, error) { return restoreUnits(c.after) }
+
+// Units returns the Units affected by the change.
+func (c Change) Units() []*unit.Unit {
+	units := make([]*unit.Unit, len(c.before))
+	for i, s := range c.before {
+		units[i] = s.unit
+	}
+	return units
+}
+
+// Mounted returns whether or not a Unit was mounted before and after the change.
+func (c Change) Mounted(u *unit.Unit) (before, after bool) {
+	for i := range c.before {
+		if c.before[i].unit == u {
+			return c.before[i].mounted, c.after[i].mounted
+		}
+	}
+	return false, false
+}
+
+// Undoable wraps an action so that the state of the Units it affects is captured before and after it's applied. The
+// result is a Change; its Data field holds the result of the action. The captured state covers whether each Unit is
+// mounted, the values and connections of its inputs, the inputs its outputs are patched into and the values of its
+// properties. If no Units are given, the routing of outputs to the Engine's output channels is captured instead.
+func Undoable(action func(*Graph) (interface{}, error), units ...*unit.Unit) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		affected := units
+		if len(affected) == 0 {
+			affected = []*unit.Unit{g.sink}
+		}
+		before := captureUnits(g, affected)
+		data, err := action(g)
+		if err != nil {
+			return data, err
+		}
+		return Change{
+			Data:   data,
+			before: before,
+			after:  captureUnits(g, affected),
+		}, nil
+	}
+}
+
+type unitState struct {
+	unit         *unit.Unit
+	mounted      bool
+	inputs       []inputState
+	destinations []inputState
+	props        map[*unit.Prop]interface{}
+}
+
+type inputState struct {
+	in       *unit.In
+	source   *unit.Out
+	delayed  bool
+	constant dsp.Valuer
+}
+
+func captureUnits(g *Graph, units []*unit.Unit) []unitState {
+	states := make([]unitState, len(units))
+	for i, u := range units {
+		s := unitState{
+			unit:    u,
+			mounted: u.AttachedTo(g.graph),
+			props:   make(map[*unit.Prop]interface{}, len(u.Prop)),
+		}
+		for _, in := range u.In {
+			s.inputs = append(s.inputs, captureInput(in))
+		}
+		if s.mounted {
+			for _, o := range u.Out {
+				for _, in := range o.Out().Destinations() {
+					s.destinations = append(s.destinations, captureInput(in))
+				}
+			}
+		}
+		for _, p := range u.Prop {
+			s.props[p] = p.Value()
+		}
+		states[i] = s
+	}
+	return states
+}
+
+// restoreUnits returns an action that restores captured Unit state. Units are mounted or detached first; then
+// connections are restored for the Units that are mounted. Units are detached, rather than unmounted, so that they
+// aren't closed while they can still be mounted again by undoing or redoing a change.
+func restoreUnits(states []unitState) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		for _, s := range states {
+			switch attached := s.unit.AttachedTo(g.graph); {
+			case s.mounted && !attached:
+				if err := g.Mount(s.unit); err != nil {
+					return nil, errors.Wrapf(err, "mount %q", s.unit.ID)
+				}
+			case !s.mounted && attached:
+				if err := g.Detach(s.unit); err != nil {
+					return nil, errors.Wrapf(err, "unmount %q", s.unit.ID)
+				}
+			}
+		}
+		for _, s := range states {
+			if !s.mounted {
+				continue
+			}
+			for _, in := range s.inputs {
+				if err := g.restoreInput(in); err != nil {
+					return nil, err
+				}
+			}
+			for _, d := range s.destinations {
+				if d.in.Unit() == nil || !d.in.Unit().AttachedTo(g.graph) {
+					continue
+				}
+				if err := g.restoreInput(d); err != nil {
+					return nil, err
+				}
+			}
+			for p, v := range s.props {
+				p.Restore(v)
+			}
+		}
+		return nil, nil
+	}
+}
+
+func captureInput(in *unit.In) inputState {
+	return inputState{in: in, source: in.Source(), delayed: in.Delayed(), constant: in.Constant()}
+}
+
+// restoreInput patches an input from an output, if the output's Unit is still mounted, or fills it with a constant.
+func (g *Graph) restoreInput(s inputState) error {
+	in, source, constant := s.in, s.source, s.constant
+	if source != nil && source.Unit().AttachedTo(g.graph) {
+		if in.Source() == source && in.Delayed() == s.delayed {
+			return nil
+		}
+		if s.delayed {
+			if err := unit.PatchFeedback(g.graph, source, in); err != nil {
+				return errors.Wrapf(err, "patch %q into %q with feedback", source, in)
+			}
+			return nil
+		}
+		if err := unit.Unpatch(g.graph, in); err != nil {
+			return errors.Wrapf(err, "unpatch %q", in)
+		}
+		if err := unit.Patch(g.graph, source, in); err != nil {
+			return errors.Wrapf(err, "patch %q into %q", source, in)
+		}
+		return nil
+	}
+	if constant == nil {
+		constant = in.Normal()
+	}
+	if err := unit.Unpatch(g.graph, in); err != nil {
+		return errors.Wrapf(err, "unpatch %q", in)
+	}
+	in.Fill(constant)
+	return nil
+}
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestUndoable_PatchInput(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		u1 = inputUnit("x", "y")
+		u2 = inputUnit()
+	)
+	require.NoError(t, g.Mount(u1))
+	require.NoError(t, g.Mount(u2))
+	_, err := PatchInput(u1, map[string]interface{}{
+		"x": unit.OutRef{Unit: u2, Output: "out"},
+		"y": dsp.Frequency(440, sampleRate),
+	}, false)(g)
+	require.NoError(t, err)
+
+	v, err := Undoable(PatchInput(u1, map[string]interface{}{"x": 0.5}, true), u1)(g)
+	require.NoError(t, err)
+	change := v.(Change)
+	require.Nil(t, u1.In["x"].Source())
+	require.Equal(t, dsp.Float64(0), u1.In["y"].Constant())
+
+	_, err = change.Undo()(g)
+	require.NoError(t, err)
+	require.Equal(t, u2.Out["out"].Out(), u1.In["x"].Source())
+	require.Equal(t, dsp.Frequency(440, sampleRate), u1.In["y"].Constant())
+
+	_, err = change.Redo()(g)
+	require.NoError(t, err)
+	require.Nil(t, u1.In["x"].Source())
+	require.Equal(t, dsp.Float64(0.5), u1.In["x"].Constant())
+}
+
+func TestUndoable_MountAndUnmount(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		u1 = inputUnit("x")
+		u2 = inputUnit()
+	)
+	v, err := Undoable(MountUnit(u1), u1)(g)
+	require.NoError(t, err)
+	mount := v.(Change)
+	before, after := mount.Mounted(u1)
+	require.False(t, before)
+	require.True(t, after)
+
+	require.NoError(t, g.Mount(u2))
+	_, err = PatchInput(u1, map[string]interface{}{"x": unit.OutRef{Unit: u2, Output: "out"}}, false)(g)
+	require.NoError(t, err)
+	_, err = EmitOutputs(unit.OutRef{Unit: u2, Output: "out"})(g)
+	require.NoError(t, err)
+
+	v, err = Undoable(UnmountUnit(u2), u2)(g)
+	require.NoError(t, err)
+	unmount := v.(Change)
+	require.False(t, u2.AttachedTo(g.graph))
+	require.Nil(t, u1.In["x"].Source())
+	require.Nil(t, g.sink.In["0"].Source())
+
+	// Undoing the unmount restores the connections from the unit's outputs; including those to the output channels.
+	_, err = unmount.Undo()(g)
+	require.NoError(t, err)
+	require.True(t, u2.AttachedTo(g.graph))
+	require.Equal(t, u2.Out["out"].Out(), u1.In["x"].Source())
+	require.Equal(t, u2.Out["out"].Out(), g.sink.In["0"].Source())
+	require.Equal(t, u2.Out["out"].Out(), g.sink.In["1"].Source())
+
+	_, err = mount.Undo()(g)
+	require.NoError(t, err)
+	require.False(t, u1.AttachedTo(g.graph))
+	require.Len(t, u2.Out["out"].Out().Destinations(), 2)
+}
+
+func TestUndoable_DetachedUnitsStayOpen(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		c = &closingConstant{}
+		u = unit.NewUnit(unit.NewIO("constant", frameSize), c)
+	)
+	c.constant = constant{u.NewOut("out"), 0.5}
+	require.NoError(t, g.Mount(u))
+
+	v, err := Undoable(DetachUnit(u), u)(g)
+	require.NoError(t, err)
+	detach := v.(Change)
+	require.False(t, u.AttachedTo(g.graph))
+	require.False(t, c.closed, "detached units can be mounted again")
+
+	// Redoing the detachment doesn't close the unit either.
+	_, err = detach.Undo()(g)
+	require.NoError(t, err)
+	require.True(t, u.AttachedTo(g.graph))
+	_, err = detach.Redo()(g)
+	require.NoError(t, err)
+	require.False(t, c.closed)
+
+	// Units that have been mounted again are left open.
+	_, err = detach.Undo()(g)
+	require.NoError(t, err)
+	_, err = CloseDetachedUnits(u)(g)
+	require.NoError(t, err)
+	require.False(t, c.closed)
+
+	_, err = detach.Redo()(g)
+	require.NoError(t, err)
+	_, err = CloseDetachedUnits(u)(g)
+	require.NoError(t, err)
+	require.True(t, c.closed)
+}
+
+func TestGraph_CloseClosesDetachedUnits(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		c = &closingConstant{}
+		u = unit.NewUnit(unit.NewIO("constant", frameSize), c)
+	)
+	c.constant = constant{u.NewOut("out"), 0.5}
+	require.NoError(t, g.Mount(u))
+	require.NoError(t, g.Detach(u))
+	require.False(t, c.closed)
+
+	require.NoError(t, g.Close())
+	require.True(t, c.closed)
+}
+
+func TestUndoable_EmitOutputs(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		u1 = inputUnit()
+		u2 = inputUnit()
+	)
+	require.NoError(t, g.Mount(u1))
+	require.NoError(t, g.Mount(u2))
+	_, err := EmitOutputs(unit.OutRef{Unit: u1, Output: "out"})(g)
+	require.NoError(t, err)
+
+	v, err := Undoable(EmitOutputs(unit.OutRef{Unit: u1, Output: "out"}, unit.OutRef{Unit: u2, Output: "out"}))(g)
+	require.NoError(t, err)
+	require.Equal(t, u2.Out["out"].Out(), g.sink.In["1"].Source())
+
+	_, err = v.(Change).Undo()(g)
+	require.NoError(t, err)
+	require.Equal(t, u1.Out["out"].Out(), g.sink.In["0"].Source())
+	require.Equal(t, u1.Out["out"].Out(), g.sink.In["1"].Source())
+}
+
+func TestUndoable_Feedback(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		u1  = inputUnit("x")
+		u2  = inputUnit()
+		in  = u1.In["x"]
+		out = u2.Out["out"].Out()
+	)
+	require.NoError(t, g.Mount(u1))
+	require.NoError(t, g.Mount(u2))
+	_, err := PatchInput(u1, map[string]interface{}{
+		"x": unit.FeedbackRef{OutRef: unit.OutRef{Unit: u2, Output: "out"}},
+	}, false)(g)
+	require.NoError(t, err)
+
+	v, err := Undoable(PatchInput(u1, map[string]interface{}{"x": unit.OutRef{Unit: u2, Output: "out"}}, false), u1)(g)
+	require.NoError(t, err)
+	require.False(t, in.Delayed())
+
+	_, err = v.(Change).Undo()(g)
+	require.NoError(t, err)
+	require.True(t, in.Delayed())
+	require.Equal(t, out, in.Source())
+
+	// Remounting a unit restores the delayed connections on either side of it.
+	for _, u := range []*unit.Unit{u1, u2} {
+		v, err = Undoable(UnmountUnit(u), u)(g)
+		require.NoError(t, err)
+		require.False(t, in.Delayed())
+		require.Empty(t, out.Destinations())
+
+		_, err = v.(Change).Undo()(g)
+		require.NoError(t, err)
+		require.True(t, in.Delayed())
+		require.Equal(t, []*unit.In{in}, out.Destinations())
+	}
+}
+
+func TestUndoable_Error(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	u := inputUnit()
+	_, err := Undoable(UnmountUnit(u), u)(g)
+	require.Error(t, err)
+}
@@ -8,7 +8,7 @@ import (
 func NewMessage(action interface{}) *Message {
 	return &Message{
 		Action: action,
-		Reply:  make(chan *Reply),
+		Reply:  make(chan *Reply, 1),
 	}
 }
 
@@ -28,32 +28,10 @@ type Reply struct {
 }
 
 // MessageChannel is abstraction of a channel that handles engine messages. This provides us a means of implementing
-// slightly more strict synchronization behavior during testing.
+// slightly more strict synchronization behavior during testing. Receive is called from the audio thread and should
+// return nil when there are no messages waiting.
 type MessageChannel interface {
 	Receive() *Message
 	Send(*Message) error
 	Close()
 }
-
-func newMessageChannel() messageChannel {
-	return messageChannel{make(chan *Message)}
-}
-
-type messageChannel struct {
-	messages chan *Message
-}
-
-func (b messageChannel) Receive() *Message {
-	select {
-	case msg := <-b.messages:
-		return msg
-	default:
-		return nil
-	}
-}
-
-func (b messageChannel) Send(msg *Message) error {
-	b.messages <- msg
-	return nil
-}
-func (b messageChannel) Close() { close(b.messages) }
@@ -0,0 +1,178 @@
+// Package pipe provides an engine backend that processes PCM audio read from a stream; writing the result to another
+// stream. This allows the engine to be used as a Unix filter.
+package pipe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brettbuddin/shaden/errors"
+)
+
+const sampleSize = 2
+
+// New returns a new Pipe that reads interleaved little-endian int16s from in and writes interleaved little-endian
+// int16s to out. No other sample format is supported.
+func New(in io.Reader, out io.Writer, frameSize, sampleRate, inChannels, outChannels int) (*Pipe, error) {
+	if inChannels < 1 {
+		return nil, errors.Errorf("input channel count must be greater than zero")
+	}
+	if outChannels < 1 {
+		return nil, errors.Errorf("output channel count must be greater than zero")
+	}
+	return &Pipe{
+		in:          in,
+		out:         bufio.NewWriter(out),
+		frameSize:   frameSize,
+		sampleRate:  sampleRate,
+		inChannels:  inChannels,
+		outChannels: outChannels,
+		record:      make(chan struct{}),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Pipe is an engine backend that feeds audio read from an input stream to the engine and writes the processed audio
+// to an output stream. Processing runs as fast as the streams allow and ends once the input stream is exhausted.
+// Nothing is read until Record is called; this allows a patch to be loaded before processing begins.
+type Pipe struct {
+	in                      io.Reader
+	out                     *bufio.Writer
+	frameSize, sampleRate   int
+	inChannels, outChannels int
+
+	recordOnce, stopOnce sync.Once
+	record, stop, done   chan struct{}
+	err                  error
+}
+
+// Start starts the backend.
+func (p *Pipe) Start(callback func([][]float32, [][]float32)) error {
+	var (
+		in     = make([][]float32, p.inChannels)
+		out    = make([][]float32, p.outChannels)
+		raw    = make([]byte, p.frameSize*p.inChannels*sampleSize)
+		period = time.Duration(float64(p.frameSize) / float64(p.sampleRate) * float64(time.Second))
+	)
+	for i := range in {
+		in[i] = make([]float32, p.frameSize)
+	}
+	for i := range out {
+		out[i] = make([]float32, p.frameSize)
+	}
+
+	go func() {
+		defer close(p.done)
+
+		// Run in real-time, with silent input, until recording begins.
+	idle:
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-p.record:
+				break idle
+			default:
+			}
+			callback(in, out)
+			time.Sleep(period)
+		}
+
+		for {
+			select {
+			case <-p.stop:
+				p.err = p.flush()
+				return
+			default:
+			}
+
+			n, err := io.ReadFull(p.in, raw)
+			frames := n / (p.inChannels * sampleSize)
+			if frames > 0 {
+				p.readFrames(raw, in, frames)
+				callback(in, out)
+				if err := p.writeFrames(out, frames); err != nil {
+					p.err = err
+					return
+				}
+			}
+
+			switch err {
+			case nil:
+			case io.EOF, io.ErrUnexpectedEOF:
+				p.err = p.flush()
+				return
+			default:
+				p.err = errors.Wrap(err, "reading frames")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Record begins reading frames from the input stream.
+func (p *Pipe) Record() {
+	p.recordOnce.Do(func() { close(p.record) })
+}
+
+// Done returns a channel that is closed once the input stream has been exhausted, or the backend has been stopped.
+func (p *Pipe) Done() <-chan struct{} { return p.done }
+
+// Stop stops the backend and waits for it to finish. A read from the input stream that is in progress is not
+// interrupted; Stop waits for it to complete.
+func (p *Pipe) Stop() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+	return p.err
+}
+
+// SampleRate returns the sample rate of the backend.
+func (p *Pipe) SampleRate() int { return p.sampleRate }
+
+// FrameSize returns the frame size of the backend.
+func (p *Pipe) FrameSize() int { return p.frameSize }
+
+// readFrames decodes n interleaved frames into the input channels; silencing the remainder of the frame.
+func (p *Pipe) readFrames(raw []byte, in [][]float32, n int) {
+	for i := 0; i < p.frameSize; i++ {
+		for c, ch := range in {
+			if i >= n {
+				ch[i] = 0
+				continue
+			}
+			offset := (i*p.inChannels + c) * sampleSize
+			ch[i] = float32(int16(binary.LittleEndian.Uint16(raw[offset:]))) / -math.MinInt16
+		}
+	}
+}
+
+func (p *Pipe) writeFrames(out [][]float32, n int) error {
+	var b [sampleSize]byte
+	for i := 0; i < n; i++ {
+		for _, ch := range out {
+			binary.LittleEndian.PutUint16(b[:], uint16(toInt16(ch[i])))
+			if _, err := p.out.Write(b[:]); err != nil {
+				return errors.Wrap(err, "writing frames")
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Pipe) flush() error {
+	if err := p.out.Flush(); err != nil {
+		return errors.Wrap(err, "flushing frames")
+	}
+	return nil
+}
+
+func toInt16(v float32) int16 {
+	return int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(float64(v)*-math.MinInt16))))
+}
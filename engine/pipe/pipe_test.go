@@ -0,0 +1,137 @@
+package pipe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/engine"
+)
+
+var _ engine.Backend = &Pipe{}
+
+func TestPipe(t *testing.T) {
+	const frameSize = 4
+
+	var (
+		samples = []int16{0, 1, -1, 16384, -16384, 32767, -32768, 100, 200, 300, -300, -200}
+		in      = bytes.NewBuffer(nil)
+		out     = bytes.NewBuffer(nil)
+	)
+	require.NoError(t, binary.Write(in, binary.LittleEndian, samples))
+
+	// Six stereo frames; the second chunk read is partial.
+	p, err := New(in, out, frameSize, 44100, 2, 2)
+	require.NoError(t, err)
+
+	err = p.Start(func(in, out [][]float32) {
+		copy(out[0], in[1])
+		copy(out[1], in[0])
+	})
+	require.NoError(t, err)
+	p.Record()
+
+	select {
+	case <-p.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for input to be exhausted")
+	}
+	require.NoError(t, p.Stop())
+
+	actual := make([]int16, out.Len()/2)
+	require.NoError(t, binary.Read(out, binary.LittleEndian, actual))
+
+	expected := make([]int16, len(samples))
+	for i := 0; i < len(samples); i += 2 {
+		expected[i], expected[i+1] = samples[i+1], samples[i]
+	}
+	require.Equal(t, expected, actual)
+}
+
+func TestPipe_Saturation(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	p, err := New(bytes.NewReader([]byte{0, 0}), out, 1, 44100, 1, 2)
+	require.NoError(t, err)
+
+	err = p.Start(func(_, out [][]float32) {
+		out[0][0] = 2
+		out[1][0] = -2
+	})
+	require.NoError(t, err)
+	p.Record()
+	<-p.Done()
+
+	actual := make([]int16, 2)
+	require.NoError(t, binary.Read(out, binary.LittleEndian, actual))
+	require.Equal(t, []int16{32767, -32768}, actual)
+}
+
+func TestPipe_StopBeforeRecord(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	p, err := New(r, ioutil.Discard, 256, 44100, 1, 2)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(func([][]float32, [][]float32) {}))
+	require.NoError(t, p.Stop())
+	<-p.Done()
+}
+
+func TestPipe_StopWaitsForRead(t *testing.T) {
+	r, w := io.Pipe()
+	in := &notifyReader{r: r, reading: make(chan struct{}, 1)}
+
+	p, err := New(in, ioutil.Discard, 1, 44100, 1, 1)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(func([][]float32, [][]float32) {}))
+	p.Record()
+
+	select {
+	case <-in.reading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for read")
+	}
+
+	stopped := make(chan error)
+	go func() { stopped <- p.Stop() }()
+
+	select {
+	case <-stopped:
+		t.Fatal("stop returned while a read was in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, w.Close())
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for stop")
+	}
+}
+
+// notifyReader signals each time a read begins.
+type notifyReader struct {
+	r       io.Reader
+	reading chan struct{}
+}
+
+func (r *notifyReader) Read(b []byte) (int, error) {
+	select {
+	case r.reading <- struct{}{}:
+	default:
+	}
+	return r.r.Read(b)
+}
+
+func TestPipe_InvalidChannels(t *testing.T) {
+	_, err := New(nil, nil, 256, 44100, 0, 2)
+	require.Error(t, err)
+	_, err = New(nil, nil, 256, 44100, 1, 0)
+	require.Error(t, err)
+}
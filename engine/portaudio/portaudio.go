@@ -52,7 +52,7 @@ func Terminate() error {
 }
 
 // New returns a new PortAudio.
-func New(inDeviceIndex, outDeviceIndex int, latency string, frameSize, sampleRate int) (*PortAudio, error) {
+func New(inDeviceIndex, outDeviceIndex int, latency string, frameSize, sampleRate, inChannels, outChannels int) (*PortAudio, error) {
 	devices, err := portaudio.Devices()
 	if err != nil {
 		return nil, err
@@ -77,8 +77,8 @@ func New(inDeviceIndex, outDeviceIndex int, latency string, frameSize, sampleRat
 	default:
 		return nil, fmt.Errorf("invalid latency setting: %q", latency)
 	}
-	params.Input.Channels = 1
-	params.Output.Channels = 2
+	params.Input.Channels = inChannels
+	params.Output.Channels = outChannels
 	params.SampleRate = float64(sampleRate)
 	params.FramesPerBuffer = frameSize
 
@@ -106,7 +106,7 @@ func (pa *PortAudio) SampleRate() int {
 }
 
 // Start starts the portaudio stream.
-func (pa *PortAudio) Start(callback func([]float32, [][]float32)) error {
+func (pa *PortAudio) Start(callback func([][]float32, [][]float32)) error {
 	var err error
 	pa.stream, err = portaudio.OpenStream(pa.params, callback)
 	if err != nil {
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brettbuddin/shaden/unit"
+)
+
+// ProfileEntry is the processing time attributed to a Unit, or a type of Unit, over a profiling window.
+type ProfileEntry struct {
+	ID    string        `json:"id,omitempty"`
+	Type  string        `json:"type"`
+	Time  time.Duration `json:"time"`
+	Share float64       `json:"share"`
+}
+
+// Profile is the processing time spent in each Unit over a window of time. Entries are sorted by descending time.
+// Units that are processed sample-by-sample as part of a feedback loop are attributed to the loop as a whole.
+type Profile struct {
+	Window time.Duration  `json:"window"`
+	Total  time.Duration  `json:"total"`
+	Units  []ProfileEntry `json:"units"`
+	Types  []ProfileEntry `json:"types"`
+}
+
+func newProfiler(window time.Duration) *profiler {
+	return &profiler{
+		window:   window,
+		counters: map[string]*profileCounter{},
+	}
+}
+
+// profiler attributes time spent in processors to the Units that own them. Counters are written and rotated into
+// completed Profiles on the audio thread; completed Profiles are read from any goroutine.
+type profiler struct {
+	window   time.Duration
+	start    time.Time
+	counters map[string]*profileCounter
+
+	mutex sync.Mutex
+	last  *Profile
+}
+
+type profileCounter struct {
+	id, typ string
+	ns      int64
+}
+
+// wrap wraps processors with timing. Counters are kept for units that remain in the graph; the counters for the
+// wrapped processors replace the current ones once they're installed.
+func (p *profiler) wrap(processors []unit.FrameProcessor) ([]unit.FrameProcessor, map[string]*profileCounter) {
+	var (
+		wrapped  = make([]unit.FrameProcessor, len(processors))
+		counters = make(map[string]*profileCounter, len(p.counters))
+	)
+	for i, proc := range processors {
+		id, typ := processorOwner(proc)
+		c, ok := counters[id]
+		if !ok {
+			if c, ok = p.counters[id]; !ok {
+				c = &profileCounter{id: id, typ: typ}
+			}
+			counters[id] = c
+		}
+		wrapped[i] = timedProcessor{proc, c}
+	}
+	return wrapped, counters
+}
+
+// tick completes the current window if it has elapsed.
+func (p *profiler) tick() {
+	now := time.Now()
+	if p.start.IsZero() {
+		p.start = now
+		return
+	}
+	elapsed := now.Sub(p.start)
+	if elapsed < p.window {
+		return
+	}
+
+	var (
+		profile = &Profile{Window: elapsed}
+		types   = map[string]time.Duration{}
+	)
+	for _, c := range p.counters {
+		d := time.Duration(atomic.SwapInt64(&c.ns, 0))
+		profile.Total += d
+		types[c.typ] += d
+		profile.Units = append(profile.Units, ProfileEntry{ID: c.id, Type: c.typ, Time: d})
+	}
+	for typ, d := range types {
+		profile.Types = append(profile.Types, ProfileEntry{Type: typ, Time: d})
+	}
+	finishEntries(profile.Units, profile.Total)
+	finishEntries(profile.Types, profile.Total)
+	p.start = now
+
+	p.mutex.Lock()
+	p.last = profile
+	p.mutex.Unlock()
+}
+
+// profile returns the most recently completed Profile, or nil if no window has completed yet.
+func (p *profiler) profile() *Profile {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.last
+}
+
+func finishEntries(entries []ProfileEntry, total time.Duration) {
+	for i := range entries {
+		if total > 0 {
+			entries[i].Share = float64(entries[i].Time) / float64(total)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Time == entries[j].Time {
+			return entries[i].ID+entries[i].Type < entries[j].ID+entries[j].Type
+		}
+		return entries[i].Time > entries[j].Time
+	})
+}
+
+// processorOwner returns the ID and type of the Unit that a processor belongs to.
+func processorOwner(p interface{}) (string, string) {
+	switch v := p.(type) {
+	case *unit.Unit:
+		if v != nil {
+			return v.ID, v.Type
+		}
+	case *unit.In:
+		return processorOwner(v.Unit())
+	case unit.Output:
+		return processorOwner(v.Out().Unit())
+	case interface{ Unit() *unit.Unit }:
+		return processorOwner(v.Unit())
+	case group:
+		ids := map[string]bool{}
+		for _, sp := range v.processors {
+			id, _ := processorOwner(sp)
+			ids[id] = true
+		}
+		list := make([]string, 0, len(ids))
+		for id := range ids {
+			list = append(list, id)
+		}
+		sort.Strings(list)
+		return "feedback(" + strings.Join(list, ",") + ")", "feedback"
+	}
+	return "unknown", "unknown"
+}
+
+type timedProcessor struct {
+	unit.FrameProcessor
+	counter *profileCounter
+}
+
+func (p timedProcessor) ProcessFrame(n int) {
+	start := time.Now()
+	p.FrameProcessor.ProcessFrame(n)
+	atomic.AddInt64(&p.counter.ns, int64(time.Since(start)))
+}
+
+func (p timedProcessor) Close() error {
+	if closer, ok := p.FrameProcessor.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestEngine_Profile(t *testing.T) {
+	be := backend{
+		start:     func(func([][]float32, [][]float32)) error { return nil },
+		stop:      func() error { return nil },
+		frameSize: frameSize,
+	}
+	e, err := New(be, frameSize, WithProfiling(0))
+	require.NoError(t, err)
+
+	io := unit.NewIO("example", frameSize)
+	io.NewOut("out")
+	u := unit.NewUnit(io, sleeper{})
+	require.NoError(t, e.graph.Mount(u))
+	_, err = EmitOutputs(unit.OutRef{Unit: u, Output: "out"})(e.graph)
+	require.NoError(t, err)
+	e.graph.Sort()
+
+	var (
+		in      = [][]float32{make([]float32, frameSize)}
+		outputs = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	)
+
+	e.callback(in, outputs)
+	_, err = e.Profile()
+	require.Error(t, err)
+
+	e.callback(in, outputs)
+	profile, err := e.Profile()
+	require.NoError(t, err)
+	require.NotEmpty(t, profile.Units)
+	require.Equal(t, u.ID, profile.Units[0].ID)
+	require.Equal(t, "example", profile.Units[0].Type)
+	require.True(t, profile.Units[0].Time >= time.Millisecond)
+	require.Equal(t, "example", profile.Types[0].Type)
+	require.Empty(t, profile.Types[0].ID)
+
+	var share float64
+	for _, entry := range profile.Units {
+		share += entry.Share
+	}
+	require.InDelta(t, 1, share, 1e-9)
+}
+
+func TestEngine_ProfileDisabled(t *testing.T) {
+	be := backend{
+		start:     func(func([][]float32, [][]float32)) error { return nil },
+		stop:      func() error { return nil },
+		frameSize: frameSize,
+	}
+	e, err := New(be, frameSize)
+	require.NoError(t, err)
+	_, err = e.Profile()
+	require.Error(t, err)
+}
+
+type sleeper struct{}
+
+func (s sleeper) ProcessSample(i int) {}
+
+func (s sleeper) ProcessFrame(n int) {
+	time.Sleep(time.Millisecond)
+}
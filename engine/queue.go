@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"runtime"
+	"sync/atomic"
+
+	"github.com/brettbuddin/shaden/errors"
+)
+
+// defaultQueueSize is the capacity of the Engine's message queue.
+const defaultQueueSize = 1024
+
+// newQueue returns a new bounded queue. The size is rounded up to a power of two.
+func newQueue(size int) *queue {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	q := &queue{
+		mask:  uint64(n - 1),
+		slots: make([]queueSlot, n),
+	}
+	for i := range q.slots {
+		q.slots[i].seq = uint64(i)
+	}
+	return q
+}
+
+// queue is a lock-free bounded multi-producer queue of Messages. It allows any number of goroutines to send messages
+// without blocking the audio thread that receives them.
+type queue struct {
+	mask   uint64
+	slots  []queueSlot
+	_      [56]byte
+	head   uint64
+	_      [56]byte
+	tail   uint64
+	_      [56]byte
+	closed int32
+}
+
+type queueSlot struct {
+	seq uint64
+	msg *Message
+}
+
+// Receive dequeues a Message; returning nil if the queue is empty.
+func (q *queue) Receive() *Message {
+	pos := atomic.LoadUint64(&q.tail)
+	for {
+		slot := &q.slots[pos&q.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+		switch diff := int64(seq) - int64(pos+1); {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&q.tail, pos, pos+1) {
+				msg := slot.msg
+				slot.msg = nil
+				atomic.StoreUint64(&slot.seq, pos+q.mask+1)
+				return msg
+			}
+		case diff < 0:
+			return nil
+		}
+		pos = atomic.LoadUint64(&q.tail)
+	}
+}
+
+// Send enqueues a Message. If the queue is full the caller yields until space is available.
+func (q *queue) Send(msg *Message) error {
+	for {
+		if atomic.LoadInt32(&q.closed) == 1 {
+			return errors.New("engine message queue is closed")
+		}
+		if q.push(msg) {
+			return nil
+		}
+		runtime.Gosched()
+	}
+}
+
+// Close closes the queue. Subsequent sends fail.
+func (q *queue) Close() { atomic.StoreInt32(&q.closed, 1) }
+
+func (q *queue) push(msg *Message) bool {
+	pos := atomic.LoadUint64(&q.head)
+	for {
+		slot := &q.slots[pos&q.mask]
+		seq := atomic.LoadUint64(&slot.seq)
+		switch diff := int64(seq) - int64(pos); {
+		case diff == 0:
+			if atomic.CompareAndSwapUint64(&q.head, pos, pos+1) {
+				slot.msg = msg
+				atomic.StoreUint64(&slot.seq, pos+1)
+				return true
+			}
+		case diff < 0:
+			return false
+		}
+		pos = atomic.LoadUint64(&q.head)
+	}
+}
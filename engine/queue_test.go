@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	q := newQueue(3)
+	require.Len(t, q.slots, 4)
+	require.Nil(t, q.Receive())
+
+	msgs := make([]*Message, 4)
+	for i := range msgs {
+		msgs[i] = NewMessage(i)
+		require.True(t, q.push(msgs[i]))
+	}
+	require.False(t, q.push(NewMessage(4)))
+
+	for _, msg := range msgs {
+		require.Equal(t, msg, q.Receive())
+	}
+	require.Nil(t, q.Receive())
+
+	q.Close()
+	require.Error(t, q.Send(NewMessage(5)))
+}
+
+func TestQueue_ConcurrentSenders(t *testing.T) {
+	const (
+		senders = 8
+		count   = 1000
+	)
+
+	var (
+		q  = newQueue(16)
+		wg sync.WaitGroup
+	)
+	wg.Add(senders)
+	for i := 0; i < senders; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				require.NoError(t, q.Send(NewMessage(i*count+j)))
+			}
+		}(i)
+	}
+
+	var (
+		seen = map[int]bool{}
+		last = make([]int, senders)
+	)
+	for i := range last {
+		last[i] = -1
+	}
+	for len(seen) < senders*count {
+		msg := q.Receive()
+		if msg == nil {
+			runtime.Gosched()
+			continue
+		}
+		n := msg.Action.(int)
+		require.False(t, seen[n])
+		seen[n] = true
+
+		// Messages from a single sender arrive in order
+		sender, j := n/count, n%count
+		require.True(t, j > last[sender])
+		last[sender] = j
+	}
+	wg.Wait()
+	require.Nil(t, q.Receive())
+}
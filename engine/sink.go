@@ -1,35 +1,46 @@
 package engine
 
 import (
+	"strconv"
+
 	"github.com/brettbuddin/shaden/dsp"
 	"github.com/brettbuddin/shaden/unit"
 )
 
-func newSink(io *unit.IO, fadeIn, sampleRate, frameSize int) *sink {
+func newSink(io *unit.IO, channels, fadeIn, sampleRate, frameSize int) *sink {
 	var (
 		fadeInSamples = dsp.DurationInt(fadeIn, sampleRate).Float64()
+		s             = &sink{channels: make([]*channel, channels)}
 	)
-	return &sink{
-		left: &channel{
-			fadeIn: fadeInSamples,
-			in:     io.NewIn("l", dsp.Float64(0)),
-			out:    make([]float64, frameSize),
-		},
-		right: &channel{
+	for i := range s.channels {
+		s.channels[i] = &channel{
 			fadeIn: fadeInSamples,
-			in:     io.NewIn("r", dsp.Float64(0)),
+			in:     io.NewIn(strconv.Itoa(i), dsp.Float64(0)),
 			out:    make([]float64, frameSize),
-		},
+		}
 	}
+	return s
 }
 
 type sink struct {
-	left, right *channel
+	channels    []*channel
+	deferFadeIn bool
 }
 
 func (s *sink) ProcessSample(i int) {
-	s.left.tick(i)
-	s.right.tick(i)
+	for _, c := range s.channels {
+		c.tick(i, s.deferFadeIn)
+	}
+}
+
+// hasSignal returns whether or not a signal has been detected on any channel.
+func (s *sink) hasSignal() bool {
+	for _, c := range s.channels {
+		if c.hasSignal {
+			return true
+		}
+	}
+	return false
 }
 
 type channel struct {
@@ -40,12 +51,15 @@ type channel struct {
 	fadeIn    float64
 }
 
-func (c *channel) tick(i int) {
+func (c *channel) tick(i int, deferFadeIn bool) {
 	in := c.in.Read(i)
 	c.out[i] = in * c.level
 	if !c.hasSignal && in != 0 {
 		c.hasSignal = true
 	}
+	if deferFadeIn && !c.hasSignal {
+		return
+	}
 	if c.level < 1 {
 		c.level += 1 / c.fadeIn
 		if c.level > 1 {
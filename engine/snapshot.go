@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/errors"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+// Snapshot holds the constant values of unpatched inputs at the time it was taken.
+type Snapshot struct {
+	values map[*unit.In]dsp.Valuer
+}
+
+// Len returns the number of inputs in the snapshot.
+func (s Snapshot) Len() int { return len(s.values) }
+
+func (s Snapshot) String() string { return fmt.Sprintf("snapshot(%d inputs)", len(s.values)) }
+
+// TakeSnapshot is an action that captures the constant values of the unpatched inputs of a set of Units. If no Units
+// are given, every Unit in the graph is captured.
+func TakeSnapshot(units ...*unit.Unit) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		if len(units) == 0 {
+			for _, n := range g.graph.Nodes() {
+				if u, ok := n.Value.(*unit.Unit); ok && u != g.sink {
+					units = append(units, u)
+				}
+			}
+		}
+		s := Snapshot{values: map[*unit.In]dsp.Valuer{}}
+		for _, u := range units {
+			for _, in := range u.In {
+				if c := in.Constant(); c != nil {
+					s.values[in] = c
+				}
+			}
+		}
+		return s, nil
+	}
+}
+
+// RecallSnapshot is an action that restores the values of a Snapshot. Inputs that have since been patched from an
+// output are left alone. Any morph in progress is stopped.
+func RecallSnapshot(s Snapshot) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		g.stopMorph()
+		for in, v := range s.values {
+			if !in.HasSource() {
+				in.Fill(v)
+			}
+		}
+		return nil, nil
+	}
+}
+
+// MorphSnapshots is an action that sets the inputs common to two Snapshots to values interpolated between them. A
+// position of 0 is the first Snapshot and 1 is the second. Any morph in progress is stopped.
+func MorphSnapshots(a, b Snapshot, position float64) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		m, err := newMorph(a, b)
+		if err != nil {
+			return nil, err
+		}
+		g.stopMorph()
+		m.apply(dsp.Clamp(position, 0, 1))
+		return nil, nil
+	}
+}
+
+// MorphSnapshotsOver is an action that morphs the inputs common to two Snapshots from the first to the second over a
+// duration (in samples). The inputs are interpolated per sample.
+func MorphSnapshotsOver(a, b Snapshot, duration int) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		if duration <= 0 {
+			return nil, errors.New("morph duration must be greater than zero")
+		}
+		m, err := newMorph(a, b)
+		if err != nil {
+			return nil, err
+		}
+		m.step = 1 / float64(duration)
+		g.stopMorph()
+		g.morph = m
+		return nil, nil
+	}
+}
+
+// MorphSnapshotsBy is an action that morphs the inputs common to two Snapshots by a control signal. The signal is
+// sampled once per frame, and the inputs are interpolated per sample between successive readings; values of 0 and below
+// select the first Snapshot and values of 1 and above the second. The morph follows the signal until it's stopped. The
+// signal must be patched into another unit; outputs without destinations aren't processed.
+func MorphSnapshotsBy(a, b Snapshot, control unit.OutRef) func(*Graph) (interface{}, error) {
+	return func(g *Graph) (interface{}, error) {
+		out, ok := control.Unit.Out[control.Output]
+		if !ok {
+			return nil, errors.Errorf("unit %q has no output %q", control.Unit.ID, control.Output)
+		}
+		if !control.Unit.AttachedTo(g.graph) || out.Out().DestinationCount() == 0 {
+			return nil, errors.Errorf("control %s isn't patched into any unit", out.Out())
+		}
+		m, err := newMorph(a, b)
+		if err != nil {
+			return nil, err
+		}
+		m.control = out.Out()
+		g.stopMorph()
+		g.morph = m
+		return nil, nil
+	}
+}
+
+// StopMorph is an action that stops any morph in progress; leaving the inputs at their current values.
+func StopMorph(g *Graph) (interface{}, error) {
+	g.stopMorph()
+	return nil, nil
+}
+
+// stopMorph clears the morph in progress. The inputs are left holding their current values across the whole frame, in
+// place of the ramp last written to them.
+func (g *Graph) stopMorph() {
+	if g.morph == nil {
+		return
+	}
+	g.morph.apply(g.morph.position)
+	g.morph = nil
+}
+
+// morph interpolates inputs between two sets of values. It's either driven by time or by a control signal.
+type morph struct {
+	targets        []morphTarget
+	control        *unit.Out
+	position, step float64
+	started        bool
+}
+
+type morphTarget struct {
+	in       *unit.In
+	from, to dsp.Valuer
+}
+
+// at returns the value of the target at a position. The ends of the morph are the values exactly as they were
+// captured.
+func (t morphTarget) at(position float64) dsp.Valuer {
+	switch position {
+	case 0:
+		return t.from
+	case 1:
+		return t.to
+	default:
+		return dsp.Float64(dsp.Lerp(t.from.Float64(), t.to.Float64(), position))
+	}
+}
+
+func newMorph(a, b Snapshot) (*morph, error) {
+	m := &morph{}
+	for in, from := range a.values {
+		if to, ok := b.values[in]; ok {
+			m.targets = append(m.targets, morphTarget{in: in, from: from, to: to})
+		}
+	}
+	if len(m.targets) == 0 {
+		return nil, errors.New("snapshots have no inputs in common")
+	}
+	return m, nil
+}
+
+// apply sets the inputs to the values at a position between the two sets of values.
+func (m *morph) apply(position float64) {
+	for _, t := range m.targets {
+		if !t.in.HasSource() {
+			t.in.Fill(t.at(position))
+		}
+	}
+}
+
+// ramp moves the inputs across a frame from the values at one position to the values at another.
+func (m *morph) ramp(from, to float64) {
+	for _, t := range m.targets {
+		if !t.in.HasSource() {
+			t.in.Ramp(t.at(from).Float64(), t.at(to))
+		}
+	}
+}
+
+// advanceMorph applies the morph in progress for a frame of n samples; clearing it on the frame after a timed morph
+// completes.
+func (g *Graph) advanceMorph(n int) {
+	m := g.morph
+	if m == nil {
+		return
+	}
+	if m.control != nil {
+		// The first reading of the signal has nothing to be interpolated from.
+		position := dsp.Clamp(m.control.Read(0), 0, 1)
+		if !m.started {
+			m.position, m.started = position, true
+		}
+		m.ramp(m.position, position)
+		m.position = position
+		return
+	}
+	if m.position >= 1 {
+		g.stopMorph()
+		return
+	}
+	next := dsp.Clamp(m.position+m.step*float64(n), 0, 1)
+	m.ramp(m.position, next)
+	m.position = next
+}
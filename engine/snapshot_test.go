@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestSnapshot_Recall(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		u1 = inputUnit("a", "b")
+		u2 = inputUnit("a")
+	)
+	require.NoError(t, g.Mount(u1))
+	require.NoError(t, g.Mount(u2))
+	_, err := PatchInput(u1, map[string]interface{}{
+		"a": dsp.Frequency(440, sampleRate),
+		"b": unit.OutRef{Unit: u2, Output: "out"},
+	}, false)(g)
+	require.NoError(t, err)
+
+	v, err := TakeSnapshot()(g)
+	require.NoError(t, err)
+	all := v.(Snapshot)
+	require.Equal(t, 2, all.Len(), "patched inputs aren't captured")
+
+	v, err = TakeSnapshot(u2)(g)
+	require.NoError(t, err)
+	require.Equal(t, 1, v.(Snapshot).Len())
+
+	_, err = PatchInput(u1, map[string]interface{}{"a": 0.5}, false)(g)
+	require.NoError(t, err)
+	_, err = PatchInput(u2, map[string]interface{}{"a": 0.25}, false)(g)
+	require.NoError(t, err)
+
+	_, err = RecallSnapshot(all)(g)
+	require.NoError(t, err)
+	require.Equal(t, dsp.Frequency(440, sampleRate), u1.In["a"].Constant())
+	require.Equal(t, dsp.Float64(0), u2.In["a"].Constant())
+}
+
+func TestSnapshot_Morph(t *testing.T) {
+	g := NewGraph(frameSize)
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	u := inputUnit("a", "b")
+	require.NoError(t, g.Mount(u))
+
+	v, err := TakeSnapshot(u)(g)
+	require.NoError(t, err)
+	a := v.(Snapshot)
+
+	_, err = PatchInput(u, map[string]interface{}{"a": 1, "b": dsp.Duration(10, sampleRate)}, false)(g)
+	require.NoError(t, err)
+	v, err = TakeSnapshot(u)(g)
+	require.NoError(t, err)
+	b := v.(Snapshot)
+
+	_, err = MorphSnapshots(a, b, 0.25)(g)
+	require.NoError(t, err)
+	require.Equal(t, 0.25, u.In["a"].Read(0))
+	require.InDelta(t, 0.25*441, u.In["b"].Read(0), 1e-9)
+
+	_, err = MorphSnapshots(a, b, 1)(g)
+	require.NoError(t, err)
+	require.Equal(t, dsp.Duration(10, sampleRate), u.In["b"].Constant(), "the end of a morph restores values exactly")
+
+	_, err = MorphSnapshots(a, Snapshot{}, 1)(g)
+	require.Error(t, err)
+}
+
+func TestEngine_MorphOver(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	e, err := New(be, frameSize)
+	require.NoError(t, err)
+
+	u := inputUnit("a")
+	require.NoError(t, e.graph.Mount(u))
+	a, err := TakeSnapshot(u)(e.graph)
+	require.NoError(t, err)
+	_, err = PatchInput(u, map[string]interface{}{"a": 1.0}, false)(e.graph)
+	require.NoError(t, err)
+	b, err := TakeSnapshot(u)(e.graph)
+	require.NoError(t, err)
+
+	_, err = MorphSnapshotsOver(a.(Snapshot), b.(Snapshot), 4*frameSize)(e.graph)
+	require.NoError(t, err)
+
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	)
+	// Each frame moves a quarter of the way; sample by sample.
+	for i := 0; i < 4; i++ {
+		e.callback(in, out)
+		require.InDelta(t, (float64(i)+1/float64(frameSize))/4, u.In["a"].Read(0), 1e-9)
+		require.InDelta(t, float64(i+1)/4, u.In["a"].Read(frameSize-1), 1e-9)
+	}
+	require.Equal(t, b.(Snapshot).values[u.In["a"]], u.In["a"].Constant(), "the end of a morph restores values exactly")
+
+	// Once the final ramp has been written the input holds the end of the morph; it doesn't replay the ramp.
+	for i := 0; i < 3; i++ {
+		e.callback(in, out)
+		require.Nil(t, e.graph.morph)
+		require.Equal(t, 1.0, u.In["a"].Read(0))
+		require.Equal(t, 1.0, u.In["a"].Read(frameSize-1))
+	}
+}
+
+func TestEngine_MorphStop(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	e, err := New(be, frameSize)
+	require.NoError(t, err)
+
+	u := inputUnit("a")
+	require.NoError(t, e.graph.Mount(u))
+	a, err := TakeSnapshot(u)(e.graph)
+	require.NoError(t, err)
+	_, err = PatchInput(u, map[string]interface{}{"a": 1.0}, false)(e.graph)
+	require.NoError(t, err)
+	b, err := TakeSnapshot(u)(e.graph)
+	require.NoError(t, err)
+
+	_, err = MorphSnapshotsOver(a.(Snapshot), b.(Snapshot), 4*frameSize)(e.graph)
+	require.NoError(t, err)
+
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	)
+	e.callback(in, out)
+	_, err = StopMorph(e.graph)
+	require.NoError(t, err)
+
+	// The input is left where the morph stopped; flat across every frame that follows.
+	for i := 0; i < 3; i++ {
+		e.callback(in, out)
+		require.Equal(t, 0.25, u.In["a"].Read(0))
+		require.Equal(t, 0.25, u.In["a"].Read(frameSize-1))
+	}
+}
+
+func TestEngine_MorphBy(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	e, err := New(be, frameSize)
+	require.NoError(t, err)
+
+	var (
+		u       = inputUnit("a")
+		control = constantUnit(0.75)
+		dest    = inputUnit("in")
+		ref     = unit.OutRef{Unit: control, Output: "out"}
+	)
+	require.NoError(t, e.graph.Mount(u))
+	require.NoError(t, e.graph.Mount(control))
+	require.NoError(t, e.graph.Mount(dest))
+
+	a, err := TakeSnapshot(u)(e.graph)
+	require.NoError(t, err)
+	_, err = PatchInput(u, map[string]interface{}{"a": 2.0}, false)(e.graph)
+	require.NoError(t, err)
+	b, err := TakeSnapshot(u)(e.graph)
+	require.NoError(t, err)
+
+	// An unpatched control would never be processed; leaving the morph where it started.
+	_, err = MorphSnapshotsBy(a.(Snapshot), b.(Snapshot), ref)(e.graph)
+	require.Error(t, err)
+	require.Nil(t, e.graph.morph)
+
+	_, err = PatchInput(dest, map[string]interface{}{"in": ref}, false)(e.graph)
+	require.NoError(t, err)
+	e.graph.Sort()
+
+	_, err = MorphSnapshotsBy(a.(Snapshot), b.(Snapshot), ref)(e.graph)
+	require.NoError(t, err)
+
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+	)
+	// The control signal is sampled before the frame is processed; so the morph follows it a frame later, moving
+	// towards it over the course of that frame.
+	e.callback(in, out)
+	require.Equal(t, 0.0, u.In["a"].Read(frameSize-1))
+	e.callback(in, out)
+	require.InDelta(t, 1.5/frameSize, u.In["a"].Read(0), 1e-9)
+	require.Equal(t, 1.5, u.In["a"].Read(frameSize-1))
+	e.callback(in, out)
+	require.Equal(t, 1.5, u.In["a"].Read(0))
+
+	_, err = StopMorph(e.graph)
+	require.NoError(t, err)
+	require.Nil(t, e.graph.morph)
+	e.callback(in, out)
+	require.Equal(t, 1.5, u.In["a"].Read(0))
+	require.Equal(t, 1.5, u.In["a"].Read(frameSize-1))
+}
+
+func inputUnit(inputs ...string) *unit.Unit {
+	io := unit.NewIO("inputs", frameSize)
+	for _, name := range inputs {
+		io.NewIn(name, dsp.Float64(0))
+	}
+	io.NewOut("out")
+	return unit.NewUnit(io, nil)
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"sync/atomic"
+)
+
+// sorter builds schedules on a background goroutine, so that sorting a large graph doesn't hold up the audio thread.
+// Once the graph has changed the audio thread requests a schedule and keeps processing the current one until the new
+// one is ready. It handles no messages in the meantime, so the graph isn't changed while it's being sorted.
+type sorter struct {
+	requests chan uint64
+	done     chan struct{}
+	ready    atomic.Value
+
+	// Only accessed by the audio thread.
+	requested uint64
+	pending   bool
+}
+
+type sorted struct {
+	seq      uint64
+	schedule *schedule
+}
+
+func newSorter(g *Graph) *sorter {
+	s := &sorter{
+		requests: make(chan uint64, 1),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		for seq := range s.requests {
+			s.ready.Store(sorted{seq: seq, schedule: g.schedule()})
+		}
+	}()
+	return s
+}
+
+// request asks for the graph to be sorted.
+func (s *sorter) request() {
+	s.requested++
+	s.pending = true
+	s.requests <- s.requested
+}
+
+// receive returns the requested schedule, if it's ready.
+func (s *sorter) receive() (*schedule, bool) {
+	v, ok := s.ready.Load().(sorted)
+	if !ok || v.seq != s.requested {
+		return nil, false
+	}
+	s.pending = false
+	return v.schedule, true
+}
+
+// close stops the sorter; waiting for a sort in progress to complete.
+func (s *sorter) close() {
+	close(s.requests)
+	<-s.done
+}
+
+// sortInBackground requests a new schedule from the sorter if the graph has changed.
+func (g *Graph) sortInBackground() {
+	if !g.graph.HasChanged() {
+		return
+	}
+	g.graph.AckChange()
+	g.sorter.request()
+}
+
+// receiveSorted installs the schedule requested from the sorter once it's ready, and closes the units it no longer
+// includes. It reports whether the graph is free to be changed.
+func (g *Graph) receiveSorted() (bool, error) {
+	if !g.sorter.pending {
+		return true, nil
+	}
+	s, ok := g.sorter.receive()
+	if !ok {
+		return false, nil
+	}
+	g.install(s)
+	return true, g.closeUnmounted()
+}
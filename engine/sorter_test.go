@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestEngine_BackgroundSort(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	e, err := New(be, frameSize, WithBackgroundSort())
+	require.NoError(t, err)
+	defer e.graph.sorter.close()
+
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+		c   = &closingConstant{}
+		u   = unit.NewUnit(unit.NewIO("constant", frameSize), c)
+	)
+	c.constant = constant{u.NewOut("out"), 0.5}
+
+	// until calls the callback until a condition holds.
+	until := func(cond func() bool) {
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			require.True(t, time.Now().Before(deadline), "timed out")
+			e.callback(in, out)
+		}
+	}
+
+	require.NoError(t, e.SendMessage(NewMessage(MountUnit(u))))
+	require.NoError(t, e.SendMessage(NewMessage(EmitOutputs(unit.OutRef{Unit: u, Output: "out"}))))
+	e.callback(in, out)
+	require.Zero(t, out[0][0], "the previous schedule is processed while the graph is sorted")
+	until(func() bool { return out[0][0] == 0.5 })
+
+	require.NoError(t, e.SendMessage(NewMessage(UnmountUnit(u))))
+	e.callback(in, out)
+	require.False(t, c.closed, "units are closed once they're no longer processed")
+	until(func() bool { return c.closed })
+	require.NotContains(t, e.graph.Processors(), u)
+}
+
+func BenchmarkEngine_Mount(b *testing.B) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	benchmarks := []struct {
+		name string
+		opts []Option
+	}{
+		{"sync", nil},
+		{"background", []Option{WithBackgroundSort()}},
+	}
+	for _, bench := range benchmarks {
+		b.Run(bench.name, func(b *testing.B) {
+			e, err := New(be, frameSize, bench.opts...)
+			require.NoError(b, err)
+			if e.graph.sorter != nil {
+				defer e.graph.sorter.close()
+			}
+			for i := 0; i < 5000; i++ {
+				require.NoError(b, e.graph.Mount(inputUnit("in")))
+			}
+			e.graph.Sort()
+
+			var (
+				in      = [][]float32{make([]float32, frameSize)}
+				out     = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+				msg     *Message
+				elapsed time.Duration
+			)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// A unit is mounted as soon as the previous one has been.
+				if msg == nil || len(msg.Reply) > 0 {
+					msg = NewMessage(MountUnit(inputUnit("in")))
+					require.NoError(b, e.SendMessage(msg))
+				}
+				start := time.Now()
+				e.callback(in, out)
+				elapsed += time.Since(start)
+			}
+			b.ReportMetric(float64(elapsed)/float64(b.N), "callback-ns/op")
+		})
+	}
+}
+
+type closingConstant struct {
+	constant
+	closed bool
+}
+
+func (c *closingConstant) Close() error {
+	c.closed = true
+	return nil
+}
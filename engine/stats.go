@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// loadSmoothing is the weight given to the measurement of each callback when averaging DSP load.
+const loadSmoothing = 0.05
+
+// Stats are measurements of the time spent processing device buffers relative to the real-time budget. Load is
+// expressed as a fraction of the budget; a load above 1 is an overrun and will likely be heard as a dropout.
+type Stats struct {
+	Budget      time.Duration `json:"budget"`
+	Callbacks   uint64        `json:"callbacks"`
+	Overruns    uint64        `json:"overruns"`
+	Load        float64       `json:"load"`
+	AverageLoad float64       `json:"average_load"`
+	PeakLoad    float64       `json:"peak_load"`
+}
+
+func newLoadMeter(frameSize, sampleRate int) *loadMeter {
+	var budget time.Duration
+	if sampleRate > 0 {
+		budget = time.Duration(float64(frameSize) / float64(sampleRate) * float64(time.Second))
+	}
+	return &loadMeter{budget: budget}
+}
+
+// loadMeter measures the time spent in callbacks on the audio thread. The audio thread only publishes its measurements
+// with atomic operations; they are aggregated into Stats by the reader.
+type loadMeter struct {
+	// Written by the audio thread. These come first to keep them 64-bit aligned.
+	callbacks, overruns uint64
+	elapsed, last, peak int64
+
+	budget time.Duration
+
+	// Read side: the average load, as of the last snapshot.
+	mutex       sync.Mutex
+	seen        uint64
+	seenElapsed int64
+	average     float64
+}
+
+func (m *loadMeter) record(elapsed time.Duration) {
+	if m.budget > 0 {
+		ns := int64(elapsed)
+		atomic.StoreInt64(&m.last, ns)
+		atomic.AddInt64(&m.elapsed, ns)
+		if ns > atomic.LoadInt64(&m.peak) {
+			atomic.StoreInt64(&m.peak, ns)
+		}
+		if elapsed > m.budget {
+			atomic.AddUint64(&m.overruns, 1)
+		}
+	}
+	atomic.AddUint64(&m.callbacks, 1)
+}
+
+func (m *loadMeter) snapshot() Stats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := Stats{
+		Budget:    m.budget,
+		Callbacks: atomic.LoadUint64(&m.callbacks),
+		Overruns:  atomic.LoadUint64(&m.overruns),
+	}
+	if m.budget == 0 {
+		return stats
+	}
+
+	// Fold the mean load of the callbacks since the last snapshot into the average; as though each of them had been
+	// averaged in turn.
+	total := atomic.LoadInt64(&m.elapsed)
+	if n := stats.Callbacks - m.seen; n > 0 {
+		mean := float64(total-m.seenElapsed) / float64(n) / float64(m.budget)
+		if m.seen == 0 {
+			m.average = mean
+		} else {
+			m.average += (1 - math.Pow(1-loadSmoothing, float64(n))) * (mean - m.average)
+		}
+		m.seen, m.seenElapsed = stats.Callbacks, total
+	}
+
+	stats.Load = float64(atomic.LoadInt64(&m.last)) / float64(m.budget)
+	stats.PeakLoad = float64(atomic.LoadInt64(&m.peak)) / float64(m.budget)
+	stats.AverageLoad = m.average
+	return stats
+}
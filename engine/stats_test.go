@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMeter(t *testing.T) {
+	m := newLoadMeter(441, 44100)
+	require.Equal(t, 10*time.Millisecond, m.snapshot().Budget)
+
+	m.record(5 * time.Millisecond)
+	stats := m.snapshot()
+	require.Equal(t, uint64(1), stats.Callbacks)
+	require.Equal(t, uint64(0), stats.Overruns)
+	require.InDelta(t, 0.5, stats.Load, 1e-9)
+	require.InDelta(t, 0.5, stats.AverageLoad, 1e-9)
+	require.InDelta(t, 0.5, stats.PeakLoad, 1e-9)
+
+	m.record(20 * time.Millisecond)
+	m.record(1 * time.Millisecond)
+	stats = m.snapshot()
+	require.Equal(t, uint64(3), stats.Callbacks)
+	require.Equal(t, uint64(1), stats.Overruns)
+	require.InDelta(t, 0.1, stats.Load, 1e-9)
+	require.InDelta(t, 2, stats.PeakLoad, 1e-9)
+	require.True(t, stats.AverageLoad > 0.5 && stats.AverageLoad < 2)
+}
+
+func TestLoadMeter_NoSampleRate(t *testing.T) {
+	m := newLoadMeter(256, 0)
+	m.record(time.Second)
+	stats := m.snapshot()
+	require.Equal(t, uint64(1), stats.Callbacks)
+	require.Equal(t, uint64(0), stats.Overruns)
+	require.Equal(t, 0.0, stats.Load)
+}
+
+func TestLoadMeter_ConcurrentSnapshot(t *testing.T) {
+	m := newLoadMeter(441, 44100)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			m.record(5 * time.Millisecond)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		m.snapshot()
+	}
+	<-done
+
+	stats := m.snapshot()
+	require.Equal(t, uint64(1000), stats.Callbacks)
+	require.InDelta(t, 0.5, stats.AverageLoad, 1e-9)
+	require.InDelta(t, 0.5, stats.PeakLoad, 1e-9)
+}
@@ -0,0 +1,97 @@
+package stdout
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/brettbuddin/shaden/errors"
+)
+
+// Format is a sample format written by Stdout.
+type Format int
+
+// Supported sample formats. All formats are interleaved and little-endian.
+const (
+	FormatS16LE Format = iota
+	FormatS24LE
+	FormatS32LE
+	FormatF32LE
+	FormatF64LE
+)
+
+var formatNames = map[Format]string{
+	FormatS16LE: "s16le",
+	FormatS24LE: "s24le",
+	FormatS32LE: "s32le",
+	FormatF32LE: "f32le",
+	FormatF64LE: "f64le",
+}
+
+// ParseFormat returns the Format with a specific name (e.g. "s16le" or "f32le").
+func ParseFormat(name string) (Format, error) {
+	for f, n := range formatNames {
+		if n == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return 0, errors.Errorf("unknown sample format %q", name)
+}
+
+func (f Format) String() string { return formatNames[f] }
+
+// Size returns the number of bytes in a single sample.
+func (f Format) Size() int {
+	switch f {
+	case FormatS16LE:
+		return 2
+	case FormatS24LE:
+		return 3
+	case FormatS32LE, FormatF32LE:
+		return 4
+	case FormatF64LE:
+		return 8
+	}
+	return 0
+}
+
+// IsInteger returns whether or not the format stores integer samples.
+func (f Format) IsInteger() bool {
+	return f == FormatS16LE || f == FormatS24LE || f == FormatS32LE
+}
+
+// max returns the largest integer value of the format.
+func (f Format) max() float64 {
+	switch f {
+	case FormatS16LE:
+		return math.MaxInt16
+	case FormatS24LE:
+		return 1<<23 - 1
+	case FormatS32LE:
+		return math.MaxInt32
+	}
+	return 1
+}
+
+// put encodes a sample into b. Integer samples are given already scaled to the range of the format; they are
+// saturated rather than allowed to wrap.
+func (f Format) put(b []byte, v float64) {
+	le := binary.LittleEndian
+	switch f {
+	case FormatS16LE:
+		le.PutUint16(b, uint16(int16(saturate(v, f.max()))))
+	case FormatS24LE:
+		s := uint32(int32(saturate(v, f.max())))
+		b[0], b[1], b[2] = byte(s), byte(s>>8), byte(s>>16)
+	case FormatS32LE:
+		le.PutUint32(b, uint32(int32(saturate(v, f.max()))))
+	case FormatF32LE:
+		le.PutUint32(b, math.Float32bits(float32(v)))
+	case FormatF64LE:
+		le.PutUint64(b, math.Float64bits(v))
+	}
+}
+
+func saturate(v, max float64) float64 {
+	return math.Max(-max-1, math.Min(max, math.Round(v)))
+}
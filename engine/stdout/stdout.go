@@ -1,53 +1,91 @@
 package stdout
 
 import (
-	"encoding/binary"
 	"io"
-	"math"
+	"math/rand"
 	"sync"
+
+	"github.com/brettbuddin/shaden/errors"
 )
 
+// Option is a configuration option for Stdout
+type Option func(*Stdout)
+
+// WithFormat sets the sample format written to the output stream.
+func WithFormat(f Format) Option {
+	return func(s *Stdout) {
+		s.format = f
+	}
+}
+
+// WithDither enables TPDF dither when writing integer sample formats.
+func WithDither() Option {
+	return func(s *Stdout) {
+		s.dither = true
+	}
+}
+
 // New returns a new Stdout
-func New(out io.Writer, frameSize, sampleRate int) *Stdout {
-	return &Stdout{
+func New(out io.Writer, frameSize, sampleRate, channels int, opts ...Option) *Stdout {
+	s := &Stdout{
 		out:        out,
 		frameSize:  frameSize,
 		sampleRate: sampleRate,
+		channels:   channels,
+		format:     FormatS16LE,
+		rand:       rand.New(rand.NewSource(1)),
 		running:    true,
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-// Stdout is an engine backend that writes little-endian int16s to an output
-// stream (stdout).
+// Stdout is an engine backend that writes interleaved samples to an output stream (stdout). By default samples are
+// written as little-endian int16s.
 type Stdout struct {
 	out                   io.Writer
 	frameSize, sampleRate int
+	channels              int
+	format                Format
+	dither                bool
+	rand                  *rand.Rand
 
 	mutex   sync.Mutex
 	running bool
+	err     error
+	done    chan struct{}
 }
 
 // Start starts the backend.
-func (s *Stdout) Start(callback func([]float32, [][]float32)) error {
+func (s *Stdout) Start(callback func([][]float32, [][]float32)) error {
 	var (
-		in  = make([]float32, s.frameSize)
-		out = [][]float32{
-			make([]float32, s.frameSize),
-			make([]float32, s.frameSize),
-		}
+		in  [][]float32
+		out = make([][]float32, s.channels)
+		buf = make([]byte, s.frameSize*s.channels*s.format.Size())
 	)
+	for i := range out {
+		out[i] = make([]float32, s.frameSize)
+	}
 
 	go func() {
+		defer close(s.done)
 		for {
 			s.mutex.Lock()
-			if !s.running {
+			running := s.running
+			s.mutex.Unlock()
+			if !running {
 				return
 			}
-			s.mutex.Unlock()
 			callback(in, out)
-			for i := 0; i < s.frameSize; i++ {
-				binary.Write(s.out, binary.LittleEndian, toInt16(out[0][i]))
-				binary.Write(s.out, binary.LittleEndian, toInt16(out[1][i]))
+			s.encode(buf, out)
+			if _, err := s.out.Write(buf); err != nil {
+				s.mutex.Lock()
+				s.err = errors.Wrap(err, "writing frames")
+				s.mutex.Unlock()
+				return
 			}
 		}
 	}()
@@ -55,12 +93,17 @@ func (s *Stdout) Start(callback func([]float32, [][]float32)) error {
 	return nil
 }
 
-// Stop stops the backend.
+// Stop stops the backend; waiting for the frame being written to complete. It returns the error, if any, that stopped
+// the output stream from being written to.
 func (s *Stdout) Stop() error {
 	s.mutex.Lock()
 	s.running = false
 	s.mutex.Unlock()
-	return nil
+
+	<-s.done
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
 }
 
 // SampleRate returns the sample rate of the backend.
@@ -69,6 +112,26 @@ func (s *Stdout) SampleRate() int { return s.sampleRate }
 // FrameSize returns the frame size of the backend.
 func (s *Stdout) FrameSize() int { return s.frameSize }
 
-func toInt16(v float32) int16 {
-	return int16(v * float32(math.MaxInt16))
+// encode interleaves a frame of channels into buf.
+func (s *Stdout) encode(buf []byte, out [][]float32) {
+	var (
+		size    = s.format.Size()
+		scale   = s.format.max()
+		integer = s.format.IsInteger()
+		offset  int
+	)
+	for i := 0; i < s.frameSize; i++ {
+		for _, ch := range out {
+			v := float64(ch[i])
+			if integer {
+				v *= scale
+				if s.dither {
+					// Triangular distribution spanning +/- 1 LSB
+					v += s.rand.Float64() - s.rand.Float64()
+				}
+			}
+			s.format.put(buf[offset:], v)
+			offset += size
+		}
+	}
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
+	"io/ioutil"
 	"math"
+	"sync"
 	"testing"
 
 	"github.com/brettbuddin/shaden/engine"
@@ -18,10 +20,10 @@ func TestStdout(t *testing.T) {
 
 	var (
 		r, w   = io.Pipe()
-		stdout = New(w, frameSize, 44100)
+		stdout = New(w, frameSize, 44100, 2)
 	)
 
-	err := stdout.Start(func(_ []float32, out [][]float32) {
+	err := stdout.Start(func(_ [][]float32, out [][]float32) {
 		for i := 0; i < frameSize; i++ {
 			out[0][i] = 1
 			out[1][i] = 1
@@ -46,5 +48,98 @@ func TestStdout(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected.Bytes(), actual)
 
+	// Stop waits for the frame being written; so keep reading until it completes.
+	go io.Copy(ioutil.Discard, r)
 	assert.NoError(t, stdout.Stop())
 }
+
+func TestStdout_WriteError(t *testing.T) {
+	r, w := io.Pipe()
+	r.Close()
+
+	var (
+		s      = New(w, 256, 44100, 2)
+		called = make(chan struct{})
+		once   sync.Once
+	)
+	assert.NoError(t, s.Start(func([][]float32, [][]float32) {
+		once.Do(func() { close(called) })
+	}))
+
+	// Once a frame has been processed it's written; even if the backend is stopped in the meantime.
+	<-called
+	assert.Error(t, s.Stop())
+}
+
+func TestStdout_Formats(t *testing.T) {
+	const frameSize = 4
+
+	var tests = []struct {
+		format   Format
+		in       float32
+		expected []byte
+	}{
+		{FormatS16LE, 0.5, []byte{0x00, 0x40}},
+		{FormatS16LE, 2, []byte{0xff, 0x7f}},
+		{FormatS16LE, -2, []byte{0x00, 0x80}},
+		{FormatS24LE, 0.5, []byte{0x00, 0x00, 0x40}},
+		{FormatS24LE, -2, []byte{0x00, 0x00, 0x80}},
+		{FormatS32LE, 2, []byte{0xff, 0xff, 0xff, 0x7f}},
+		{FormatS32LE, -1, []byte{0x01, 0x00, 0x00, 0x80}},
+		{FormatF32LE, 0.5, []byte{0x00, 0x00, 0x00, 0x3f}},
+		{FormatF64LE, 0.5, []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xe0, 0x3f}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			var (
+				s   = New(nil, frameSize, 44100, 2, WithFormat(tt.format))
+				buf = make([]byte, frameSize*2*tt.format.Size())
+				out = [][]float32{make([]float32, frameSize), make([]float32, frameSize)}
+			)
+			for i := 0; i < frameSize; i++ {
+				out[0][i] = tt.in
+				out[1][i] = tt.in
+			}
+			s.encode(buf, out)
+			for i := 0; i < len(buf); i += tt.format.Size() {
+				assert.Equal(t, tt.expected, buf[i:i+tt.format.Size()])
+			}
+		})
+	}
+}
+
+func TestStdout_Dither(t *testing.T) {
+	const frameSize = 1024
+
+	var (
+		s       = New(nil, frameSize, 44100, 1, WithDither())
+		buf     = make([]byte, frameSize*2)
+		out     = [][]float32{make([]float32, frameSize)}
+		samples = make([]int16, frameSize)
+	)
+	for i := range out[0] {
+		out[0][i] = 0.25
+	}
+	s.encode(buf, out)
+	assert.NoError(t, binary.Read(bytes.NewReader(buf), binary.LittleEndian, samples))
+
+	var varied bool
+	for _, v := range samples {
+		assert.InDelta(t, 0.25*math.MaxInt16, float64(v), 2)
+		if v != samples[0] {
+			varied = true
+		}
+	}
+	assert.True(t, varied)
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("F32LE")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatF32LE, f)
+	assert.Equal(t, 4, f.Size())
+
+	_, err = ParseFormat("u8")
+	assert.Error(t, err)
+}
@@ -0,0 +1,267 @@
+// Package wav provides an offline engine backend that renders to a WAV file.
+package wav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/brettbuddin/shaden/errors"
+)
+
+const (
+	formatPCM   = 1
+	formatFloat = 3
+
+	headerSize = 44
+)
+
+// New returns a new WAV that renders a fixed duration of audio. Supported bit depths are 16 and 24 (integer PCM) and 32
+// (IEEE float).
+func New(out io.WriteSeeker, frameSize, sampleRate, channels, bitDepth int, duration time.Duration) (*WAV, error) {
+	if err := checkFormat(channels, bitDepth); err != nil {
+		return nil, err
+	}
+	if duration <= 0 {
+		return nil, errors.Errorf("duration must be greater than zero")
+	}
+	return &WAV{
+		out:        out,
+		buf:        bufio.NewWriter(out),
+		frameSize:  frameSize,
+		sampleRate: sampleRate,
+		channels:   channels,
+		bitDepth:   bitDepth,
+		frames:     int(math.Round(duration.Seconds() * float64(sampleRate))),
+		record:     make(chan struct{}),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Encode writes channels of audio, of equal length, to an output stream as a WAV file. The bit depths supported are the
+// same as those of New.
+func Encode(out io.Writer, sampleRate, bitDepth int, channels [][]float32) error {
+	if err := checkFormat(len(channels), bitDepth); err != nil {
+		return err
+	}
+	var (
+		w      = &WAV{buf: bufio.NewWriter(out), sampleRate: sampleRate, channels: len(channels), bitDepth: bitDepth}
+		frames = len(channels[0])
+	)
+	if err := w.writeHeader(w.buf, frames); err != nil {
+		return err
+	}
+	if err := w.writeFrames(channels, frames); err != nil {
+		return err
+	}
+	if err := w.pad(); err != nil {
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		return errors.Wrap(err, "flushing frames")
+	}
+	return nil
+}
+
+func checkFormat(channels, bitDepth int) error {
+	switch bitDepth {
+	case 16, 24, 32:
+	default:
+		return errors.Errorf("unsupported bit depth %d", bitDepth)
+	}
+	if channels < 1 {
+		return errors.Errorf("channel count must be greater than zero")
+	}
+	return nil
+}
+
+// WAV is an engine backend that drives the engine as fast as possible and writes a WAV file. Frames rendered before
+// Record is called are discarded; this allows a patch to be loaded before rendering begins.
+type WAV struct {
+	out                   io.WriteSeeker
+	buf                   *bufio.Writer
+	frameSize, sampleRate int
+	channels, bitDepth    int
+	frames, written       int
+
+	recordOnce, stopOnce sync.Once
+	record, stop, done   chan struct{}
+	err                  error
+}
+
+// Start starts the backend.
+func (w *WAV) Start(callback func([][]float32, [][]float32)) error {
+	if err := w.writeHeader(w.out, 0); err != nil {
+		return err
+	}
+
+	var (
+		in     [][]float32
+		out    = make([][]float32, w.channels)
+		period = time.Duration(float64(w.frameSize) / float64(w.sampleRate) * float64(time.Second))
+	)
+	for i := range out {
+		out[i] = make([]float32, w.frameSize)
+	}
+
+	go func() {
+		defer close(w.done)
+
+		// Run in real-time until recording begins.
+	idle:
+		for {
+			select {
+			case <-w.stop:
+				w.err = w.finish()
+				return
+			case <-w.record:
+				break idle
+			default:
+			}
+			callback(in, out)
+			time.Sleep(period)
+		}
+
+		for w.written < w.frames {
+			select {
+			case <-w.stop:
+				w.err = w.finish()
+				return
+			default:
+			}
+			callback(in, out)
+			n := w.frames - w.written
+			if n > w.frameSize {
+				n = w.frameSize
+			}
+			if err := w.writeFrames(out, n); err != nil {
+				w.err = err
+				return
+			}
+		}
+		w.err = w.finish()
+	}()
+
+	return nil
+}
+
+// Record begins writing rendered frames to the output.
+func (w *WAV) Record() {
+	w.recordOnce.Do(func() { close(w.record) })
+}
+
+// Done returns a channel that is closed once the requested duration has been written, or the backend has been
+// stopped.
+func (w *WAV) Done() <-chan struct{} { return w.done }
+
+// Stop stops the backend; finalizing the WAV file.
+func (w *WAV) Stop() error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+	return w.err
+}
+
+// SampleRate returns the sample rate of the backend.
+func (w *WAV) SampleRate() int { return w.sampleRate }
+
+// FrameSize returns the frame size of the backend.
+func (w *WAV) FrameSize() int { return w.frameSize }
+
+func (w *WAV) writeFrames(out [][]float32, n int) error {
+	var b [4]byte
+	for i := 0; i < n; i++ {
+		for _, ch := range out {
+			var size int
+			switch w.bitDepth {
+			case 16:
+				binary.LittleEndian.PutUint16(b[:], uint16(int16(quantize(ch[i], math.MaxInt16))))
+				size = 2
+			case 24:
+				v := uint32(quantize(ch[i], 1<<23-1))
+				b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+				size = 3
+			case 32:
+				binary.LittleEndian.PutUint32(b[:], math.Float32bits(ch[i]))
+				size = 4
+			}
+			if _, err := w.buf.Write(b[:size]); err != nil {
+				return errors.Wrap(err, "writing frames")
+			}
+		}
+	}
+	w.written += n
+	return nil
+}
+
+// pad aligns the end of the data chunk to two bytes, as chunks are; an odd sized data chunk is followed by a pad byte.
+func (w *WAV) pad() error {
+	if w.dataSize(w.written)%2 != 0 {
+		if err := w.buf.WriteByte(0); err != nil {
+			return errors.Wrap(err, "writing pad byte")
+		}
+	}
+	return nil
+}
+
+func (w *WAV) finish() error {
+	if err := w.pad(); err != nil {
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		return errors.Wrap(err, "flushing frames")
+	}
+	if _, err := w.out.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "seeking to header")
+	}
+	if err := w.writeHeader(w.out, w.written); err != nil {
+		return err
+	}
+	_, err := w.out.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (w *WAV) writeHeader(out io.Writer, frames int) error {
+	var (
+		format     = formatPCM
+		blockAlign = w.channels * w.bitDepth / 8
+		dataSize   = w.dataSize(frames)
+		riffSize   = headerSize - 8 + dataSize + dataSize%2
+		header     = make([]byte, headerSize)
+		le         = binary.LittleEndian
+	)
+	if w.bitDepth == 32 {
+		format = formatFloat
+	}
+
+	copy(header[0:], "RIFF")
+	le.PutUint32(header[4:], uint32(riffSize))
+	copy(header[8:], "WAVE")
+	copy(header[12:], "fmt ")
+	le.PutUint32(header[16:], 16)
+	le.PutUint16(header[20:], uint16(format))
+	le.PutUint16(header[22:], uint16(w.channels))
+	le.PutUint32(header[24:], uint32(w.sampleRate))
+	le.PutUint32(header[28:], uint32(w.sampleRate*blockAlign))
+	le.PutUint16(header[32:], uint16(blockAlign))
+	le.PutUint16(header[34:], uint16(w.bitDepth))
+	copy(header[36:], "data")
+	le.PutUint32(header[40:], uint32(dataSize))
+
+	if _, err := out.Write(header); err != nil {
+		return errors.Wrap(err, "writing header")
+	}
+	return nil
+}
+
+// dataSize returns the size of a number of frames in bytes.
+func (w *WAV) dataSize(frames int) int {
+	return frames * w.channels * w.bitDepth / 8
+}
+
+func quantize(v float32, max float64) int32 {
+	return int32(math.Round(math.Max(-1, math.Min(1, float64(v))) * max))
+}
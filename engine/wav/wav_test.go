@@ -0,0 +1,151 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-audio/wav"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/engine"
+)
+
+var _ engine.Backend = &WAV{}
+
+func TestWAV(t *testing.T) {
+	const (
+		frameSize  = 256
+		sampleRate = 44100
+	)
+
+	var tests = []struct {
+		bitDepth int
+		expected float32
+	}{
+		{16, 0.5},
+		{24, 0.5},
+		{32, 0.5},
+	}
+
+	for _, tt := range tests {
+		f, err := ioutil.TempFile("", "shaden-wav")
+		require.NoError(t, err)
+		defer os.Remove(f.Name())
+
+		w, err := New(f, frameSize, sampleRate, 2, tt.bitDepth, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		err = w.Start(func(_ [][]float32, out [][]float32) {
+			for i := 0; i < frameSize; i++ {
+				out[0][i] = 0.5
+				out[1][i] = -0.5
+			}
+		})
+		require.NoError(t, err)
+		w.Record()
+
+		select {
+		case <-w.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for render")
+		}
+		require.NoError(t, w.Stop())
+
+		_, err = f.Seek(0, 0)
+		require.NoError(t, err)
+
+		d := wav.NewDecoder(f)
+		require.True(t, d.IsValidFile())
+		require.Equal(t, uint16(2), d.NumChans)
+		require.Equal(t, uint32(sampleRate), d.SampleRate)
+		require.Equal(t, uint16(tt.bitDepth), d.BitDepth)
+
+		if tt.bitDepth == 32 {
+			require.Equal(t, uint16(formatFloat), d.WavAudioFormat)
+			continue
+		}
+
+		buf, err := d.FullPCMBuffer()
+		require.NoError(t, err)
+		require.Equal(t, 441, buf.NumFrames())
+
+		data := buf.AsFloat32Buffer().Data
+		require.InDelta(t, tt.expected, data[0], 0.001)
+		require.InDelta(t, -tt.expected, data[1], 0.001)
+		require.NoError(t, f.Close())
+	}
+}
+
+func TestWAV_StopBeforeRecord(t *testing.T) {
+	f, err := ioutil.TempFile("", "shaden-wav")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	w, err := New(f, 256, 44100, 2, 16, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, w.Start(func([][]float32, [][]float32) {}))
+	require.NoError(t, w.Stop())
+
+	info, err := f.Stat()
+	require.NoError(t, err)
+	require.Equal(t, int64(headerSize), info.Size())
+}
+
+func TestWAV_InvalidBitDepth(t *testing.T) {
+	_, err := New(nil, 256, 44100, 2, 8, time.Second)
+	require.Error(t, err)
+}
+
+func TestWAV_PadByte(t *testing.T) {
+	const (
+		frameSize  = 256
+		sampleRate = 44100
+	)
+
+	f, err := ioutil.TempFile("", "shaden-wav")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// 441 frames of a single 24-bit channel is an odd number of bytes.
+	w, err := New(f, frameSize, sampleRate, 1, 24, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.NoError(t, w.Start(func(_ [][]float32, out [][]float32) {}))
+	w.Record()
+	<-w.Done()
+	require.NoError(t, w.Stop())
+
+	b, err := ioutil.ReadFile(f.Name())
+	require.NoError(t, err)
+	require.Len(t, b, headerSize+441*3+1)
+	require.Equal(t, uint32(len(b)-8), binary.LittleEndian.Uint32(b[4:]))
+	require.Equal(t, uint32(441*3), binary.LittleEndian.Uint32(b[40:]))
+}
+
+func TestEncode(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		channels = [][]float32{{0.5, 0.25, 0}, {-0.5, -0.25, 0}}
+	)
+	require.NoError(t, Encode(&buf, 44100, 16, channels))
+
+	d := wav.NewDecoder(bytes.NewReader(buf.Bytes()))
+	require.True(t, d.IsValidFile())
+	require.Equal(t, uint16(2), d.NumChans)
+	require.Equal(t, uint16(16), d.BitDepth)
+
+	pcm, err := d.FullPCMBuffer()
+	require.NoError(t, err)
+	require.Equal(t, 3, pcm.NumFrames())
+	data := pcm.AsFloat32Buffer().Data
+	require.InDelta(t, 0.25, data[2], 0.001)
+	require.InDelta(t, -0.25, data[3], 0.001)
+
+	require.Error(t, Encode(&buf, 44100, 8, channels))
+	require.Error(t, Encode(&buf, 44100, 16, nil))
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/brettbuddin/shaden/graph"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+// workerPool processes the independent processors of a level of the graph concurrently. The goroutine processing the
+// graph takes part in the work; so a pool of n workers runs n-1 goroutines.
+type workerPool struct {
+	workers int
+	wake    chan struct{}
+	wg      sync.WaitGroup
+
+	// Work for the level currently being processed. It's published to the workers by sending on wake.
+	level []unit.FrameProcessor
+	n     int
+	next  int64
+}
+
+func newWorkerPool(workers int) *workerPool {
+	p := &workerPool{
+		workers: workers,
+		wake:    make(chan struct{}, workers),
+	}
+	for i := 1; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *workerPool) work() {
+	for range p.wake {
+		p.drain()
+		p.wg.Done()
+	}
+}
+
+// process processes a frame for every processor in a level; returning once all of them have finished.
+func (p *workerPool) process(level []unit.FrameProcessor, n int) {
+	if len(level) < 2 {
+		for _, proc := range level {
+			proc.ProcessFrame(n)
+		}
+		return
+	}
+
+	p.level, p.n, p.next = level, n, 0
+
+	helpers := p.workers - 1
+	if helpers > len(level)-1 {
+		helpers = len(level) - 1
+	}
+	p.wg.Add(helpers)
+	for i := 0; i < helpers; i++ {
+		p.wake <- struct{}{}
+	}
+	p.drain()
+	p.wg.Wait()
+}
+
+// drain processes processors of the current level until none are left.
+func (p *workerPool) drain() {
+	for {
+		i := int(atomic.AddInt64(&p.next, 1) - 1)
+		if i >= len(p.level) {
+			return
+		}
+		p.level[i].ProcessFrame(p.n)
+	}
+}
+
+// close stops the workers.
+func (p *workerPool) close() { close(p.wake) }
+
+// levelsOf groups processors into levels that can be processed concurrently. Each component of the sorted graph is
+// placed one level after the latest of the components it depends upon. Components that don't yield a processor pass
+// their level through to their dependents. collect is called for each component and returns how many processors it
+// appended to processors.
+func levelsOf(components [][]*graph.Node, collect func([]*graph.Node) int) []int {
+	var (
+		ready  = map[*graph.Node]int{}
+		levels []int
+	)
+	for _, nodes := range components {
+		level := 0
+		for _, n := range nodes {
+			for _, in := range n.InNeighbors() {
+				if l, ok := ready[in]; ok && l > level {
+					level = l
+				}
+			}
+		}
+		out := level
+		if count := collect(nodes); count > 0 {
+			for i := 0; i < count; i++ {
+				levels = append(levels, level)
+			}
+			out = level + 1
+		}
+		for _, n := range nodes {
+			ready[n] = out
+		}
+	}
+	return levels
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestEngine_Workers(t *testing.T) {
+	render := func(workers int) [][]float32 {
+		be := backend{
+			start:      func(func([][]float32, [][]float32)) error { return nil },
+			stop:       func() error { return nil },
+			sampleRate: sampleRate,
+			frameSize:  frameSize,
+		}
+		e, err := New(be, frameSize, WithWorkers(workers))
+		require.NoError(t, err)
+		if workers > 1 {
+			defer e.graph.pool.close()
+		}
+		voices(t, e.graph, 8)
+
+		var (
+			in  = [][]float32{make([]float32, 4*frameSize)}
+			out = [][]float32{make([]float32, 4*frameSize), make([]float32, 4*frameSize)}
+		)
+		e.chunks = 4
+		e.callback(in, out)
+		return out
+	}
+
+	expected := render(1)
+	require.NotZero(t, expected[0][frameSize-1])
+	for _, workers := range []int{2, 4, 8} {
+		require.Equal(t, expected, render(workers), "output with %d workers", workers)
+	}
+}
+
+func TestEngine_WorkersRandomOutputs(t *testing.T) {
+	render := func(workers int, outputs ...string) [][]float32 {
+		be := backend{
+			start:      func(func([][]float32, [][]float32)) error { return nil },
+			stop:       func() error { return nil },
+			sampleRate: sampleRate,
+			frameSize:  frameSize,
+		}
+		e, err := New(be, frameSize, WithWorkers(workers))
+		require.NoError(t, err)
+		if workers > 1 {
+			defer e.graph.pool.close()
+		}
+
+		// The noise and cluster outputs of a single unit are processed within the same level.
+		gen, err := unit.Builders()["gen"](unit.Config{SampleRate: sampleRate, FrameSize: frameSize})
+		require.NoError(t, err)
+		require.NoError(t, e.graph.Mount(gen))
+		refs := make([]unit.OutRef, len(outputs))
+		for i, name := range outputs {
+			refs[i] = unit.OutRef{Unit: gen, Output: name}
+		}
+		_, err = EmitOutputs(refs...)(e.graph)
+		require.NoError(t, err)
+		e.graph.Sort()
+
+		var (
+			in  = [][]float32{make([]float32, 4*frameSize)}
+			out = [][]float32{make([]float32, 4*frameSize), make([]float32, 4*frameSize)}
+		)
+		e.chunks = 4
+		e.callback(in, out)
+		return out
+	}
+
+	expected := render(1, "noise", "cluster")
+	require.NotZero(t, expected[0][frameSize-1])
+	for _, workers := range []int{2, 4} {
+		require.Equal(t, expected, render(workers, "noise", "cluster"), "output with %d workers", workers)
+	}
+
+	// Each output draws from its own random source; so it's unaffected by whether the others are processed, or in
+	// which order.
+	require.Equal(t, expected[0], render(1, "noise")[0])
+}
+
+func TestEngine_WorkersInvalid(t *testing.T) {
+	be := backend{
+		start:      func(func([][]float32, [][]float32)) error { return nil },
+		stop:       func() error { return nil },
+		sampleRate: sampleRate,
+		frameSize:  frameSize,
+	}
+	_, err := New(be, frameSize, WithWorkers(0))
+	require.Error(t, err)
+}
+
+func TestGraph_Levels(t *testing.T) {
+	g := NewGraph(frameSize)
+	g.pool = newWorkerPool(2)
+	defer g.pool.close()
+	require.NoError(t, g.Reset(0, frameSize, sampleRate))
+
+	var (
+		a = constantUnit(1)
+		b = constantUnit(2)
+	)
+	require.NoError(t, g.Mount(a))
+	require.NoError(t, g.Mount(b))
+	_, err := EmitOutputs(unit.OutRef{Unit: a, Output: "out"}, unit.OutRef{Unit: b, Output: "out"})(g)
+	require.NoError(t, err)
+	g.Sort()
+
+	// The constants are independent of each other; only the sink depends on them.
+	require.Len(t, g.processors, 3)
+	require.Len(t, g.levels, 2)
+	require.Len(t, g.levels[0], 2)
+	require.Len(t, g.levels[1], 1)
+	require.Equal(t, g.sink, g.levels[1][0])
+}
+
+// voices mounts a patch of independent voices that are summed together and passed through a feedback loop before
+// being emitted.
+func voices(t *testing.T, g *Graph, count int) {
+	var (
+		builders = unit.Builders()
+		config   = unit.Config{SampleRate: sampleRate, FrameSize: frameSize}
+		mix      unit.OutRef
+	)
+	build := func(typ string) *unit.Unit {
+		u, err := builders[typ](config)
+		require.NoError(t, err)
+		require.NoError(t, g.Mount(u))
+		return u
+	}
+	patch := func(v interface{}, u *unit.Unit, in string) {
+		require.NoError(t, g.Patch(v, u.In[in]))
+	}
+
+	for i := 0; i < count; i++ {
+		var (
+			osc = build("gen")
+			lfo = build("gen")
+			vca = build("mult")
+		)
+		patch(dsp.Frequency(float64(110*(i+1)), sampleRate), osc, "freq")
+		patch(dsp.Frequency(float64(i+1), sampleRate), lfo, "freq")
+		patch(unit.OutRef{Unit: osc, Output: "sine"}, vca, "x")
+		patch(unit.OutRef{Unit: lfo, Output: "saw"}, vca, "y")
+
+		voice := unit.OutRef{Unit: vca, Output: "out"}
+		if i == 0 {
+			mix = voice
+			continue
+		}
+		sum := build("sum")
+		patch(mix, sum, "x")
+		patch(voice, sum, "y")
+		mix = unit.OutRef{Unit: sum, Output: "out"}
+	}
+
+	feedback := build("diff")
+	patch(mix, feedback, "x")
+	patch(unit.OutRef{Unit: feedback, Output: "out"}, feedback, "y")
+
+	_, err := EmitOutputs(mix, unit.OutRef{Unit: feedback, Output: "out"})(g)
+	require.NoError(t, err)
+	g.Sort()
+}
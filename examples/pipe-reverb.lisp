@@ -0,0 +1,17 @@
+; Reverb for stereo audio read from stdin. Intended for use with the pipe backend:
+;
+;   sox in.wav -t raw -r 44100 -e signed -b 16 -c 2 - | \
+;     shaden -backend pipe -input-channels 2 examples/pipe-reverb.lisp | \
+;     sox -t raw -r 44100 -e signed -b 16 -c 2 - out.wav
+
+(define source (unit/source))
+(define reverb (unit/reverb))
+
+(-> reverb
+    (table :a (<- source "0")
+           :b (<- source "1")
+           :mix 0.3
+           :decay 0.8
+           :size 0.5))
+
+(emit (<- reverb :a) (<- reverb :b))
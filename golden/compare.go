@@ -0,0 +1,122 @@
+package golden
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/errors"
+)
+
+const (
+	spectrumSize  = 1024
+	spectrumFloor = -120.0
+)
+
+// Tolerance is the maximum allowed difference between two renders. A zero Spectral tolerance disables spectral
+// comparison.
+type Tolerance struct {
+	RMS, Peak, Spectral float64
+}
+
+// DefaultTolerance is suitable for catching audible changes while permitting floating-point noise.
+var DefaultTolerance = Tolerance{
+	RMS:  1e-4,
+	Peak: 1e-3,
+}
+
+// Diff describes the difference between two renders. RMS and Peak are measured on the sample-wise difference of the
+// signals. Spectral is the RMS difference (in decibels) of the average magnitude spectra of the signals.
+type Diff struct {
+	RMS, Peak, Spectral float64
+}
+
+// Within reports whether the Diff falls within a Tolerance.
+func (d Diff) Within(t Tolerance) bool {
+	if d.RMS > t.RMS || d.Peak > t.Peak {
+		return false
+	}
+	return t.Spectral == 0 || d.Spectral <= t.Spectral
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("rms=%g peak=%g spectral=%gdB", d.RMS, d.Peak, d.Spectral)
+}
+
+// Compare measures the difference between an expected and actual render. Both must have the same number of channels
+// and samples.
+func Compare(expected, actual [][]float32) (Diff, error) {
+	var d Diff
+	if len(expected) != len(actual) {
+		return d, errors.Errorf("channel count mismatch: expected %d, got %d", len(expected), len(actual))
+	}
+
+	var (
+		sum   float64
+		count int
+	)
+	for i := range expected {
+		if len(expected[i]) != len(actual[i]) {
+			return d, errors.Errorf("channel %d length mismatch: expected %d, got %d",
+				i, len(expected[i]), len(actual[i]))
+		}
+		for j := range expected[i] {
+			diff := math.Abs(float64(expected[i][j]) - float64(actual[i][j]))
+			sum += diff * diff
+			d.Peak = math.Max(d.Peak, diff)
+			count++
+		}
+		d.Spectral = math.Max(d.Spectral, spectralDiff(expected[i], actual[i]))
+	}
+	if count > 0 {
+		d.RMS = math.Sqrt(sum / float64(count))
+	}
+	return d, nil
+}
+
+func spectralDiff(a, b []float32) float64 {
+	var (
+		sa  = spectrum(a)
+		sb  = spectrum(b)
+		sum float64
+	)
+	for i := range sa {
+		diff := decibels(sa[i]) - decibels(sb[i])
+		sum += diff * diff
+	}
+	return math.Sqrt(sum / float64(len(sa)))
+}
+
+// spectrum returns the average magnitude spectrum of a signal using Hann-windowed frames with 50% overlap.
+func spectrum(s []float32) []float64 {
+	var (
+		mag    = make([]float64, spectrumSize/2)
+		buf    = make([]complex128, spectrumSize)
+		frames int
+	)
+	for offset := 0; offset+spectrumSize <= len(s); offset += spectrumSize / 2 {
+		for i := range buf {
+			w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(spectrumSize-1))
+			buf[i] = complex(float64(s[offset+i])*w, 0)
+		}
+		dsp.FFT(buf)
+		for i := range mag {
+			mag[i] += cmplx.Abs(buf[i])
+		}
+		frames++
+	}
+	if frames > 0 {
+		for i := range mag {
+			mag[i] /= float64(frames)
+		}
+	}
+	return mag
+}
+
+func decibels(v float64) float64 {
+	if v <= 0 {
+		return spectrumFloor
+	}
+	return math.Max(20*math.Log10(v), spectrumFloor)
+}
@@ -0,0 +1,198 @@
+// Package golden provides deterministic offline rendering of patches and comparison against stored golden audio.
+//
+// The patches in testdata are rendered and compared against their golden WAV files by the package tests. After an
+// intentional change in sound the golden files can be regenerated with:
+//
+//	go test ./golden -update
+package golden
+
+import (
+	"io/ioutil"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/errors"
+	"github.com/brettbuddin/shaden/runtime"
+)
+
+// Options are options for rendering a patch.
+type Options struct {
+	Seed                  int64
+	Duration              time.Duration
+	SampleRate, FrameSize int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Seed == 0 {
+		o.Seed = 1
+	}
+	if o.Duration == 0 {
+		o.Duration = time.Second
+	}
+	if o.SampleRate == 0 {
+		o.SampleRate = 44100
+	}
+	if o.FrameSize == 0 {
+		o.FrameSize = 256
+	}
+	return o
+}
+
+// Render loads a patch file into a fresh Engine and renders it offline. Rendering begins once the patch has been
+// completely evaluated. The result is a slice of channels (left and right).
+func Render(path string, opts Options) ([][]float32, error) {
+	opts = opts.withDefaults()
+	// Patches that call rand or rand-intn must evaluate the same way on every render for their output to match.
+	rand.Seed(opts.Seed)
+
+	var (
+		be       = newBackend(opts.FrameSize, opts.SampleRate)
+		messages = newMessageChannel()
+	)
+
+	e, err := engine.New(
+		be,
+		opts.FrameSize,
+		engine.WithMessageChannel(messages),
+		engine.WithMessageLimit(1),
+		engine.WithSeed(opts.Seed),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "engine create failed")
+	}
+	go e.Run()
+	go func() {
+		for range e.Errors() {
+		}
+	}()
+	defer e.Stop()
+
+	run, err := runtime.New(e, log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		return nil, errors.Wrap(err, "start lisp runtime failed")
+	}
+
+	var (
+		callback = <-be.callback
+		loaded   = make(chan error, 1)
+		in       = [][]float32{make([]float32, opts.FrameSize)}
+		out      = [][]float32{
+			make([]float32, opts.FrameSize),
+			make([]float32, opts.FrameSize),
+		}
+	)
+
+	go func() {
+		loaded <- run.Load(path)
+		messages.finishLoading()
+	}()
+
+	// Process a frame for each message sent during evaluation of the patch.
+	for {
+		callback(in, out)
+		messages.reply()
+
+		select {
+		case err := <-loaded:
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		break
+	}
+
+	var (
+		frames   = int(math.Round(opts.Duration.Seconds() * float64(opts.SampleRate)))
+		rendered = [][]float32{
+			make([]float32, 0, frames),
+			make([]float32, 0, frames),
+		}
+	)
+	for len(rendered[0]) < frames {
+		callback(in, out)
+		n := frames - len(rendered[0])
+		if n > opts.FrameSize {
+			n = opts.FrameSize
+		}
+		for i := range rendered {
+			rendered[i] = append(rendered[i], out[i][:n]...)
+		}
+	}
+
+	return rendered, nil
+}
+
+func newBackend(frameSize, sampleRate int) *backend {
+	return &backend{
+		callback:   make(chan func([][]float32, [][]float32), 1),
+		frameSize:  frameSize,
+		sampleRate: sampleRate,
+	}
+}
+
+// backend hands the engine's callback over to Render so it can drive processing directly.
+type backend struct {
+	callback              chan func([][]float32, [][]float32)
+	frameSize, sampleRate int
+}
+
+func (b *backend) Start(cb func([][]float32, [][]float32)) error {
+	b.callback <- cb
+	return nil
+}
+func (*backend) Stop() error       { return nil }
+func (b *backend) FrameSize() int  { return b.frameSize }
+func (b *backend) SampleRate() int { return b.sampleRate }
+
+func newMessageChannel() *messageChannel {
+	return &messageChannel{
+		messages: make(chan *engine.Message),
+		loaded:   make(chan struct{}),
+	}
+}
+
+// messageChannel blocks the engine until a message is sent or the patch has been loaded. Replies are withheld until
+// the frame handling the message has been processed, so evaluation of the patch and audio processing never run
+// concurrently.
+type messageChannel struct {
+	messages chan *engine.Message
+	loaded   chan struct{}
+	pending  *engine.Message
+	proxy    chan *engine.Reply
+}
+
+func (c *messageChannel) Receive() *engine.Message {
+	select {
+	case msg := <-c.messages:
+		if msg.Reply == nil {
+			return msg
+		}
+		c.pending = msg
+		c.proxy = make(chan *engine.Reply, 1)
+		return &engine.Message{Action: msg.Action, Reply: c.proxy}
+	case <-c.loaded:
+		return nil
+	}
+}
+
+func (c *messageChannel) Send(msg *engine.Message) error {
+	c.messages <- msg
+	return nil
+}
+
+func (c *messageChannel) Close() { close(c.messages) }
+
+func (c *messageChannel) reply() {
+	if c.pending == nil {
+		return
+	}
+	c.pending.Reply <- <-c.proxy
+	c.pending = nil
+}
+
+func (c *messageChannel) finishLoading() { close(c.loaded) }
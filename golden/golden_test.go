@@ -0,0 +1,186 @@
+package golden
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var renderOptions = Options{
+	Seed:       1,
+	Duration:   500 * time.Millisecond,
+	SampleRate: 22050,
+	FrameSize:  256,
+}
+
+func TestGolden(t *testing.T) {
+	patches, err := filepath.Glob(filepath.Join("testdata", "*.lisp"))
+	require.NoError(t, err)
+	require.NotEmpty(t, patches)
+
+	for _, patch := range patches {
+		name := strings.TrimSuffix(filepath.Base(patch), ".lisp")
+		t.Run(name, func(t *testing.T) {
+			actual, err := Render(patch, renderOptions)
+			require.NoError(t, err)
+
+			path := strings.TrimSuffix(patch, ".lisp") + ".wav"
+			if *update {
+				require.NoError(t, WriteFile(path, renderOptions.SampleRate, actual))
+				return
+			}
+
+			sampleRate, expected, err := ReadFile(path)
+			require.NoError(t, err, "run with -update to create golden files")
+			require.Equal(t, renderOptions.SampleRate, sampleRate)
+
+			diff, err := Compare(expected, actual)
+			require.NoError(t, err)
+			require.True(t, diff.Within(DefaultTolerance), "render differs from golden file: %s", diff)
+		})
+	}
+}
+
+func TestRender_Deterministic(t *testing.T) {
+	path := filepath.Join("testdata", "random.lisp")
+
+	a, err := Render(path, renderOptions)
+	require.NoError(t, err)
+	b, err := Render(path, renderOptions)
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+
+	opts := renderOptions
+	opts.Seed = 2
+	c, err := Render(path, opts)
+	require.NoError(t, err)
+	require.NotEqual(t, a, c)
+}
+
+func TestRender_Error(t *testing.T) {
+	f, err := ioutil.TempFile("", "shaden-golden")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`(define gen (unit/gen)) (-> gen (table :freq missing))`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = Render(f.Name(), renderOptions)
+	require.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	var (
+		a = [][]float32{{0, 0.5, -0.5, 0}}
+		b = [][]float32{{0, 0.5, -0.5, 0.2}}
+	)
+
+	diff, err := Compare(a, a)
+	require.NoError(t, err)
+	require.Equal(t, Diff{}, diff)
+	require.True(t, diff.Within(DefaultTolerance))
+
+	diff, err = Compare(a, b)
+	require.NoError(t, err)
+	require.InDelta(t, 0.1, diff.RMS, 1e-6)
+	require.InDelta(t, 0.2, diff.Peak, 1e-6)
+	require.False(t, diff.Within(DefaultTolerance))
+
+	_, err = Compare(a, [][]float32{{0}})
+	require.Error(t, err)
+	_, err = Compare(a, append(a, a[0]))
+	require.Error(t, err)
+}
+
+func TestCompare_Spectral(t *testing.T) {
+	var (
+		low  = make([]float32, 4096)
+		high = make([]float32, 4096)
+	)
+	for i := range low {
+		low[i] = float32(sin(440, i))
+		high[i] = float32(sin(880, i))
+	}
+
+	diff, err := Compare([][]float32{low}, [][]float32{high})
+	require.NoError(t, err)
+	require.True(t, diff.Spectral > 10)
+	require.False(t, diff.Within(Tolerance{RMS: 2, Peak: 2, Spectral: 1}))
+	require.True(t, diff.Within(Tolerance{RMS: 2, Peak: 2}))
+}
+
+func TestWAVRoundTrip(t *testing.T) {
+	f, err := ioutil.TempFile("", "shaden-golden")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	defer os.Remove(f.Name())
+
+	channels := [][]float32{{0, 0.25, 1}, {-1, -0.25, 0}}
+	require.NoError(t, WriteFile(f.Name(), 48000, channels))
+
+	sampleRate, read, err := ReadFile(f.Name())
+	require.NoError(t, err)
+	require.Equal(t, 48000, sampleRate)
+	require.Equal(t, channels, read)
+}
+
+func TestReadFile_Chunks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shaden-golden")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name string, numChans uint16, chunks ...[]byte) string {
+		format := chunk("fmt ", le(uint16(formatFloat), numChans, uint32(48000), uint32(48000*4*uint32(numChans)),
+			uint16(4*numChans), uint16(32)))
+		body := append([]byte("WAVE"), format...)
+		for _, c := range chunks {
+			body = append(body, c...)
+		}
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, chunk("RIFF", body), 0644))
+		return path
+	}
+
+	// Unknown chunks of an odd size are followed by a pad byte.
+	path := write("odd.wav", 1, chunk("LIST", []byte{1, 2, 3}), chunk("data", le(float32(0.5), float32(-0.5))))
+	sampleRate, channels, err := ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 48000, sampleRate)
+	require.Equal(t, [][]float32{{0.5, -0.5}}, channels)
+
+	_, _, err = ReadFile(write("silent.wav", 0, chunk("data", le(float32(0.5)))))
+	require.Error(t, err)
+}
+
+// chunk returns a RIFF chunk; padded to an even size.
+func chunk(id string, data []byte) []byte {
+	c := append([]byte(id), le(uint32(len(data)))...)
+	c = append(c, data...)
+	if len(data)%2 == 1 {
+		c = append(c, 0)
+	}
+	return c
+}
+
+func le(values ...interface{}) []byte {
+	var buf bytes.Buffer
+	for _, v := range values {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+func sin(freq float64, i int) float64 {
+	return math.Sin(2 * math.Pi * freq * float64(i) / 44100)
+}
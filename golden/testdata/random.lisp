@@ -0,0 +1,19 @@
+; Exercises units that depend on the random seed.
+(define clock (unit/clock))
+(define series (unit/random-series))
+(define chance (unit/chance))
+(define source (unit/gen))
+(define noise-gate (unit/gate))
+(define tone-gate (unit/gate))
+(define mix (unit/mix (table :size 2)))
+
+(-> clock (table :tempo (hz 16)))
+(-> series (table :clock (<- clock) :min (hz 200) :max (hz 800) :length 8))
+(-> chance (table :in (<- clock)))
+(-> source (table :freq (<- series :value)))
+(-> noise-gate (table :in (<- source :noise) :control (<- chance :a)))
+(-> tone-gate (table :in (<- source :saw) :control (<- chance :b)))
+(-> mix (list (table :in (<- noise-gate))
+              (table :in (<- tone-gate))))
+
+(emit (<- mix))
@@ -0,0 +1,110 @@
+package golden
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/brettbuddin/shaden/engine/wav"
+	"github.com/brettbuddin/shaden/errors"
+)
+
+const formatFloat = 3
+
+// WriteFile writes channels of audio to a 32-bit floating point WAV file.
+func WriteFile(path string, sampleRate int, channels [][]float32) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := wav.Encode(f, sampleRate, 32, channels); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadFile reads a 32-bit floating point WAV file; returning its sample rate and channels of audio.
+func ReadFile(path string) (int, [][]float32, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	r := bytes.NewReader(raw)
+	var riff struct {
+		ID   [4]byte
+		Size uint32
+		Wave [4]byte
+	}
+	if err := binary.Read(r, binary.LittleEndian, &riff); err != nil {
+		return 0, nil, errors.Wrap(err, "reading riff header")
+	}
+	if string(riff.ID[:]) != "RIFF" || string(riff.Wave[:]) != "WAVE" {
+		return 0, nil, errors.Errorf("%q is not a valid WAV file", path)
+	}
+
+	var (
+		format struct {
+			AudioFormat, NumChans uint16
+			SampleRate, ByteRate  uint32
+			BlockAlign, BitDepth  uint16
+		}
+		hasFormat bool
+	)
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &chunk); err != nil {
+			if err == io.EOF {
+				return 0, nil, errors.Errorf("%q has no data chunk", path)
+			}
+			return 0, nil, errors.Wrap(err, "reading chunk header")
+		}
+
+		switch string(chunk.ID[:]) {
+		case "fmt ":
+			if err := binary.Read(io.LimitReader(r, int64(chunk.Size)), binary.LittleEndian, &format); err != nil {
+				return 0, nil, errors.Wrap(err, "reading format chunk")
+			}
+			if format.AudioFormat != formatFloat || format.BitDepth != 32 {
+				return 0, nil, errors.Errorf("%q is not a 32-bit floating point WAV file", path)
+			}
+			if format.NumChans == 0 {
+				return 0, nil, errors.Errorf("%q has no channels", path)
+			}
+			hasFormat = true
+			if _, err := r.Seek(int64(chunk.Size)-16+int64(chunk.Size&1), io.SeekCurrent); err != nil {
+				return 0, nil, err
+			}
+		case "data":
+			if !hasFormat {
+				return 0, nil, errors.Errorf("%q has no format chunk", path)
+			}
+			var (
+				numChans = int(format.NumChans)
+				samples  = make([]float32, chunk.Size/4)
+				channels = make([][]float32, numChans)
+			)
+			if err := binary.Read(r, binary.LittleEndian, samples); err != nil {
+				return 0, nil, errors.Wrap(err, "reading data chunk")
+			}
+			for i := range channels {
+				channels[i] = make([]float32, 0, len(samples)/numChans)
+			}
+			for i, s := range samples {
+				channels[i%numChans] = append(channels[i%numChans], s)
+			}
+			return int(format.SampleRate), channels, nil
+		default:
+			// Chunks are padded to an even size.
+			if _, err := r.Seek(int64(chunk.Size)+int64(chunk.Size&1), io.SeekCurrent); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+}
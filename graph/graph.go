@@ -3,67 +3,129 @@ package graph
 
 import (
 	"fmt"
+	"sort"
 )
 
-// Graph is a directed graph.
+// Graph is a directed graph. It maintains a topological order of its strongly connected components as connections are
+// made and removed, rather than sorting the whole graph after each change.
 type Graph struct {
-	nodes      []*Node
-	sorted     []*Node
-	components [][]*Node
-	dirty      bool
+	first, last *Node
+	size        int
+
+	// slots holds the components in topological order; the position of a component is its ord. Removed components
+	// leave holes behind until the slots are compacted.
+	slots []*component
+	holes int
+
+	sorted [][]*Node
+	epoch  int
+	dirty  bool
+}
+
+// component is a strongly connected component of the Graph.
+type component struct {
+	nodes    []*Node
+	ord      int
+	fwd, bwd int
 }
 
 // New returns a new Graph.
 func New() *Graph {
 	return &Graph{
-		sorted:     make([]*Node, 0, 1024),
-		components: make([][]*Node, 0, 1024),
+		sorted: make([][]*Node, 0, 1024),
 	}
 }
 
 // Size returns the number of nodes in the graph.
-func (g *Graph) Size() int { return len(g.nodes) }
+func (g *Graph) Size() int { return g.size }
+
+// Nodes returns the Nodes in the Graph in the order they were added.
+func (g *Graph) Nodes() []*Node {
+	nodes := make([]*Node, 0, g.size)
+	for n := g.first; n != nil; n = n.next {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
 
-// NewNode creates a new Node in the Graph.
+// NewNode creates a new Node in the Graph. Nodes without connections may be placed anywhere in the order; new Nodes
+// are placed at the end, so connecting existing Nodes to them leaves the order as it is.
 func (g *Graph) NewNode(v interface{}) *Node {
 	n := &Node{
 		graph: g,
-		idx:   len(g.nodes),
+		prev:  g.last,
 		Value: v,
 	}
-	g.nodes = append(g.nodes, n)
+	if g.last != nil {
+		g.last.next = n
+	} else {
+		g.first = n
+	}
+	g.last = n
+	g.size++
+
+	if g.holes > len(g.slots)-g.holes {
+		g.compact()
+	}
+	c := &component{nodes: []*Node{n}, ord: len(g.slots)}
+	n.component = c
+	g.slots = append(g.slots, c)
 	return n
 }
 
-// RemoveNode removes a Node from the Graph.
+// RemoveNode removes a Node from the Graph. Only the Node's connections are visited; unless the Node is part of a
+// cycle, the order of the rest of the Graph is left as it is.
 func (g *Graph) RemoveNode(n *Node) error {
 	if !g.Exists(n) {
 		return NotInGraphError{Node: n}
 	}
 
-	for _, node := range g.nodes {
-		if node == n {
+	for _, output := range n.outputs {
+		if output.end == n {
 			continue
 		}
-		removed, err := node.removeOutConnection(n)
-		if err != nil {
+		if _, err := output.end.removeInConnection(n); err != nil {
 			return err
 		}
-		g.maybeDirty(removed)
-
-		removed, err = node.removeInConnection(n)
-		if err != nil {
+	}
+	for _, input := range n.inputs {
+		if input.end == n {
+			continue
+		}
+		if _, err := input.end.removeOutConnection(n); err != nil {
 			return err
 		}
-		g.maybeDirty(removed)
+	}
+	n.outputs, n.inputs = nil, nil
 
-		if node.idx > n.idx {
-			g.dirty = true
-			node.idx--
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else {
+		g.first = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else {
+		g.last = n.prev
+	}
+	n.prev, n.next, n.graph = nil, nil, nil
+	g.size--
+
+	c := n.component
+	n.component = nil
+	if len(c.nodes) == 1 {
+		g.slots[c.ord] = nil
+		g.holes++
+	} else {
+		nodes := make([]*Node, 0, len(c.nodes)-1)
+		for _, m := range c.nodes {
+			if m != n {
+				nodes = append(nodes, m)
+			}
 		}
+		g.split(c, nodes)
 	}
-	copy(g.nodes[n.idx:], g.nodes[n.idx+1:])
-	g.nodes = g.nodes[:len(g.nodes)-1]
+	g.dirty = true
 
 	return nil
 }
@@ -84,6 +146,7 @@ func (g *Graph) NewConnection(from, to *Node) error {
 	g.dirty = true
 	from.outputs = append(from.outputs, connection{from, to})
 	to.inputs = append(to.inputs, connection{to, from})
+	g.reorder(from.component, to.component)
 	return nil
 }
 
@@ -108,68 +171,257 @@ func (g *Graph) RemoveConnection(from, to *Node) error {
 	}
 	g.maybeDirty(removed)
 
+	// Removing a connection never invalidates the order; but it can break a cycle.
+	if removed && from.component == to.component {
+		g.split(from.component, from.component.nodes)
+	}
 	return nil
 }
 
 // Exists checks whether the Node exists in the graph.
 func (g *Graph) Exists(n *Node) bool {
-	return n != nil && n.idx < len(g.nodes) && g.nodes[n.idx] == n
+	return n != nil && n.graph == g
 }
 
-// Sorted returns a topologically sorted list of strongly connected components in the Graph.
+// Component returns the Nodes of the strongly connected component a Node is part of. The returned list is only valid
+// until the Graph is changed.
+func (g *Graph) Component(n *Node) []*Node {
+	if !g.Exists(n) {
+		return nil
+	}
+	return n.component.nodes
+}
+
+// Sorted returns a topologically sorted list of strongly connected components in the Graph. The returned lists are
+// only valid until the Graph is changed.
 func (g *Graph) Sorted() [][]*Node {
-	var (
-		components = g.components[:0]
-		sorted     = g.topSort()
-	)
-	for i := range sorted {
-		sorted[i].searchState = unseen
-	}
-	for _, sink := range sorted {
-		if g.nodes[sink.idx].searchState == unseen {
-			var group []*Node
-			g.dfsInputs(g.nodes[sink.idx], &group)
-			components = append(components, group)
+	if g.holes > len(g.slots)-g.holes {
+		g.compact()
+	}
+	components := g.sorted[:0]
+	for _, c := range g.slots {
+		if c != nil {
+			components = append(components, c.nodes)
 		}
 	}
+	g.sorted = components
 	return components
 }
 
-// TopologicalSort returns a list of nodes in topological order.
-func (g *Graph) topSort() []*Node {
-	for i := range g.nodes {
-		g.nodes[i].searchState = unseen
+// reorder restores the topological order after a connection is made between two components; following the algorithm
+// of Pearce and Kelly. Only the components ordered between the two are visited. If the connection closes a cycle, the
+// components along it are merged.
+func (g *Graph) reorder(from, to *component) {
+	if from == to || from.ord < to.ord {
+		return
+	}
+
+	g.epoch++
+	var forward, backward []*component
+	cycle := g.searchForward(to, from, &forward)
+	g.searchBackward(from, to.ord, &backward)
+
+	pool := make([]int, 0, len(forward)+len(backward))
+	for _, c := range forward {
+		pool = append(pool, c.ord)
+	}
+	for _, c := range backward {
+		if c.fwd != g.epoch {
+			pool = append(pool, c.ord)
+		}
+	}
+	sort.Ints(pool)
+	sortComponents(forward)
+	sortComponents(backward)
+
+	if !cycle {
+		// Components that reach from move ahead of the components reachable from to; keeping their relative order.
+		for i, c := range append(backward, forward...) {
+			g.place(c, pool[i])
+		}
+		return
+	}
+
+	// The components reachable from to that also reach from form the cycle. Components that only reach the cycle go
+	// before it and those only reachable from it go after it; the slots left over become holes.
+	var before, cycled, after []*component
+	for _, c := range backward {
+		if c.fwd == g.epoch {
+			cycled = append(cycled, c)
+		} else {
+			before = append(before, c)
+		}
+	}
+	for _, c := range forward {
+		if c.bwd != g.epoch {
+			after = append(after, c)
+		}
+	}
+	var nodes []*Node
+	for _, c := range cycled {
+		nodes = append(nodes, c.nodes...)
+	}
+	for _, ord := range pool {
+		g.slots[ord] = nil
+	}
+	for i, c := range before {
+		g.place(c, pool[i])
+	}
+	for i, c := range after {
+		g.place(c, pool[len(pool)-len(after)+i])
+	}
+	merged := cycled[0]
+	merged.nodes = nil
+	for _, group := range g.condense(nodes) {
+		merged.nodes = append(merged.nodes, group...)
+	}
+	g.place(merged, pool[len(before)])
+	g.holes += len(cycled) - 1
+}
+
+// searchForward collects the components reachable from c that are ordered no later than target. It reports whether
+// target is reachable.
+func (g *Graph) searchForward(c, target *component, visited *[]*component) bool {
+	c.fwd = g.epoch
+	*visited = append(*visited, c)
+	found := c == target
+	for _, n := range c.nodes {
+		for _, output := range n.outputs {
+			next := output.end.component
+			if next.fwd == g.epoch || next.ord > target.ord {
+				continue
+			}
+			if g.searchForward(next, target, visited) {
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// searchBackward collects the components that reach c and are ordered no earlier than bound.
+func (g *Graph) searchBackward(c *component, bound int, visited *[]*component) {
+	c.bwd = g.epoch
+	*visited = append(*visited, c)
+	for _, n := range c.nodes {
+		for _, input := range n.inputs {
+			prev := input.end.component
+			if prev.bwd == g.epoch || prev.ord < bound {
+				continue
+			}
+			g.searchBackward(prev, bound, visited)
+		}
+	}
+}
+
+// split recomputes the strongly connected components among the remaining nodes of c after a connection within it has
+// been removed. If c falls apart, the components that replace it are inserted at its position; shifting the components
+// after it along.
+func (g *Graph) split(c *component, nodes []*Node) {
+	groups := g.condense(nodes)
+	c.nodes = groups[0]
+	for _, n := range c.nodes {
+		n.component = c
+	}
+	if len(groups) == 1 {
+		return
 	}
-	sorted := g.sorted[:0]
-	for _, node := range g.nodes {
-		if node.searchState == unseen {
-			g.dfsOutputs(node, &sorted)
+
+	shift := len(groups) - 1
+	g.slots = append(g.slots, make([]*component, shift)...)
+	copy(g.slots[c.ord+1+shift:], g.slots[c.ord+1:len(g.slots)-shift])
+	for i := c.ord + 1 + shift; i < len(g.slots); i++ {
+		if g.slots[i] != nil {
+			g.slots[i].ord = i
+		}
+	}
+	for i, group := range groups[1:] {
+		g.place(&component{nodes: group}, c.ord+1+i)
+	}
+}
+
+// condense returns the strongly connected components formed by the connections among nodes, in topological order.
+func (g *Graph) condense(nodes []*Node) [][]*Node {
+	g.epoch++
+	member := g.epoch
+	for _, n := range nodes {
+		n.mark = member
+	}
+
+	g.epoch++
+	sorted := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.mark == member {
+			g.dfsOutputs(n, member, &sorted)
 		}
 	}
 	reverseNodes(sorted)
-	return sorted
+
+	ordered := g.epoch
+	g.epoch++
+	var groups [][]*Node
+	for _, n := range sorted {
+		if n.mark == ordered {
+			var group []*Node
+			g.dfsInputs(n, ordered, &group)
+			groups = append(groups, group)
+		}
+	}
+	return groups
 }
 
-func (g *Graph) dfsOutputs(node *Node, list *[]*Node) {
-	node.searchState = seen
+// dfsOutputs appends the unvisited nodes reachable from node in post-order. Unvisited nodes are marked with unseen.
+func (g *Graph) dfsOutputs(node *Node, unseen int, list *[]*Node) {
+	node.mark = g.epoch
 	for _, output := range node.outputs {
-		if output.end.searchState == unseen {
-			g.dfsOutputs(output.end, list)
+		if output.end.mark == unseen {
+			g.dfsOutputs(output.end, unseen, list)
 		}
 	}
 	*list = append(*list, node)
 }
 
-func (g *Graph) dfsInputs(node *Node, list *[]*Node) {
-	node.searchState = seen
+// dfsInputs appends the unvisited nodes that reach node in post-order. Unvisited nodes are marked with unseen.
+func (g *Graph) dfsInputs(node *Node, unseen int, list *[]*Node) {
+	node.mark = g.epoch
 	for _, input := range node.inputs {
-		if input.end.searchState == unseen {
-			g.dfsInputs(input.end, list)
+		if input.end.mark == unseen {
+			g.dfsInputs(input.end, unseen, list)
 		}
 	}
 	*list = append(*list, node)
 }
 
+// place moves a component to a slot.
+func (g *Graph) place(c *component, ord int) {
+	c.ord = ord
+	g.slots[ord] = c
+	for _, n := range c.nodes {
+		n.component = c
+	}
+}
+
+// compact removes the holes between components.
+func (g *Graph) compact() {
+	j := 0
+	for _, c := range g.slots {
+		if c != nil {
+			g.slots[j] = c
+			c.ord = j
+			j++
+		}
+	}
+	for i := j; i < len(g.slots); i++ {
+		g.slots[i] = nil
+	}
+	g.slots = g.slots[:j]
+	g.holes = 0
+}
+
+func sortComponents(components []*component) {
+	sort.Slice(components, func(i, j int) bool { return components[i].ord < components[j].ord })
+}
+
 func (g *Graph) maybeDirty(changed bool) {
 	if changed {
 		g.dirty = true
@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -19,6 +20,18 @@ func TestNewNode(t *testing.T) {
 	require.Equal(t, 3, g.Size())
 }
 
+func TestNodes(t *testing.T) {
+	g := New()
+
+	a := g.NewNode("a")
+	b := g.NewNode("b")
+	c := g.NewNode("c")
+	require.Equal(t, []*Node{a, b, c}, g.Nodes())
+
+	require.Nil(t, g.RemoveNode(b))
+	require.Equal(t, []*Node{a, c}, g.Nodes())
+}
+
 func TestNodeRemoval(t *testing.T) {
 	g := New()
 
@@ -30,21 +43,15 @@ func TestNodeRemoval(t *testing.T) {
 	require.Equal(t, 1, a.OutNeighborCount())
 	require.Equal(t, 1, b.InNeighborCount())
 
-	path := []string{}
-	for _, n := range g.topSort() {
-		path = append(path, n.Value.(string))
-	}
-	require.Equal(t, []string{"c", "a", "b"}, path)
+	path := order(g)
+	require.Equal(t, []string{"a", "b", "c"}, path)
 
 	require.Nil(t, g.RemoveNode(a))
 	require.Equal(t, 0, b.InNeighborCount())
 	require.NotNil(t, g.RemoveNode(&Node{}))
 
-	path = []string{}
-	for _, n := range g.topSort() {
-		path = append(path, n.Value.(string))
-	}
-	require.Equal(t, []string{"c", "b"}, path)
+	path = order(g)
+	require.Equal(t, []string{"b", "c"}, path)
 }
 
 func TestNewConnection(t *testing.T) {
@@ -78,11 +85,8 @@ func TestNewConnection(t *testing.T) {
 	require.Equal(t, b, c.InNeighbors()[0])
 	require.Equal(t, 0, c.OutNeighborCount())
 
-	path := []string{}
-	for _, n := range g.topSort() {
-		path = append(path, n.Value.(string))
-	}
-	require.Equal(t, []string{"d", "a", "b", "c"}, path)
+	path := order(g)
+	require.Equal(t, []string{"a", "b", "c", "d"}, path)
 }
 
 func TestConnectionRemoval(t *testing.T) {
@@ -95,10 +99,7 @@ func TestConnectionRemoval(t *testing.T) {
 	require.Equal(t, 1, a.OutNeighborCount())
 	require.Equal(t, 1, b.InNeighborCount())
 
-	path := []string{}
-	for _, n := range g.topSort() {
-		path = append(path, n.Value.(string))
-	}
+	path := order(g)
 	require.Equal(t, []string{"a", "b"}, path)
 
 	unknown := &Node{}
@@ -111,11 +112,9 @@ func TestConnectionRemoval(t *testing.T) {
 
 	require.NoError(t, g.RemoveConnection(b, a))
 
-	path = []string{}
-	for _, n := range g.topSort() {
-		path = append(path, n.Value.(string))
-	}
-	require.Equal(t, []string{"b", "a"}, path)
+	// Removing a connection leaves the order as it was.
+	path = order(g)
+	require.Equal(t, []string{"a", "b"}, path)
 }
 
 func TestSCC(t *testing.T) {
@@ -138,8 +137,28 @@ func TestSCC(t *testing.T) {
 		}
 	}
 	require.Equal(t, 2, len(path))
-	require.Equal(t, path[0], []string{"d"})
-	require.Equal(t, path[1], []string{"b", "c", "a"})
+	require.Equal(t, path[0], []string{"b", "c", "a"})
+	require.Equal(t, path[1], []string{"d"})
+}
+
+func TestComponent(t *testing.T) {
+	g := New()
+
+	a := g.NewNode("a")
+	b := g.NewNode("b")
+	c := g.NewNode("c")
+
+	require.NoError(t, g.NewConnection(a, b))
+	require.Equal(t, []*Node{a}, g.Component(a))
+
+	require.NoError(t, g.NewConnection(b, a))
+	require.ElementsMatch(t, []*Node{a, b}, g.Component(a))
+	require.Equal(t, g.Component(a), g.Component(b))
+	require.Equal(t, []*Node{c}, g.Component(c))
+
+	require.NoError(t, g.RemoveNode(b))
+	require.Equal(t, []*Node{a}, g.Component(a))
+	require.Nil(t, g.Component(b))
 }
 
 func BenchmarkSCC(b *testing.B) {
@@ -174,3 +193,167 @@ func TestDirty(t *testing.T) {
 	g.AckChange()
 	require.False(t, g.HasChanged())
 }
+
+func TestSCC_Split(t *testing.T) {
+	g := New()
+
+	a := g.NewNode("a")
+	b := g.NewNode("b")
+	c := g.NewNode("c")
+	d := g.NewNode("d")
+
+	require.NoError(t, g.NewConnection(a, b))
+	require.NoError(t, g.NewConnection(b, c))
+	require.NoError(t, g.NewConnection(c, a))
+	require.NoError(t, g.NewConnection(c, d))
+	require.Equal(t, [][]string{{"b", "c", "a"}, {"d"}}, components(g))
+
+	g.AckChange()
+	require.NoError(t, g.RemoveConnection(c, a))
+	require.True(t, g.HasChanged())
+	require.Equal(t, [][]string{{"a"}, {"b"}, {"c"}, {"d"}}, components(g))
+
+	require.NoError(t, g.NewConnection(c, a))
+	require.NoError(t, g.RemoveNode(b))
+	require.Equal(t, [][]string{{"c"}, {"a"}, {"d"}}, components(g))
+	require.Equal(t, 3, g.Size())
+}
+
+func TestSorted_Incremental(t *testing.T) {
+	var (
+		rnd   = rand.New(rand.NewSource(1))
+		g     = New()
+		nodes []*Node
+	)
+	for i := 0; i < 2000; i++ {
+		switch op := rnd.Intn(10); {
+		case op < 2 || len(nodes) < 2:
+			nodes = append(nodes, g.NewNode(i))
+		case op < 3:
+			j := rnd.Intn(len(nodes))
+			require.NoError(t, g.RemoveNode(nodes[j]))
+			nodes = append(nodes[:j], nodes[j+1:]...)
+		case op < 5:
+			from := nodes[rnd.Intn(len(nodes))]
+			if from.OutNeighborCount() > 0 {
+				to := from.OutNeighbors()[rnd.Intn(from.OutNeighborCount())]
+				require.NoError(t, g.RemoveConnection(from, to))
+			}
+		default:
+			require.NoError(t, g.NewConnection(nodes[rnd.Intn(len(nodes))], nodes[rnd.Intn(len(nodes))]))
+		}
+		if i%50 == 0 {
+			requireSorted(t, g, nodes)
+		}
+	}
+	requireSorted(t, g, nodes)
+}
+
+// requireSorted checks the sorted components of a Graph against the strongly connected components found by
+// comparing reachability between all of its nodes.
+func requireSorted(t *testing.T, g *Graph, nodes []*Node) {
+	reach := map[*Node]map[*Node]bool{}
+	for _, n := range nodes {
+		seen := map[*Node]bool{}
+		stack := []*Node{n}
+		for len(stack) > 0 {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, o := range m.OutNeighbors() {
+				if !seen[o] {
+					seen[o] = true
+					stack = append(stack, o)
+				}
+			}
+		}
+		reach[n] = seen
+	}
+
+	position := map[*Node]int{}
+	for i, c := range g.Sorted() {
+		for _, n := range c {
+			_, dup := position[n]
+			require.False(t, dup)
+			position[n] = i
+		}
+	}
+	require.Len(t, position, len(nodes))
+	require.Len(t, g.Nodes(), len(nodes))
+
+	for _, a := range nodes {
+		for _, b := range nodes {
+			if a == b {
+				continue
+			}
+			cyclic := reach[a][b] && reach[b][a]
+			require.Equal(t, cyclic, position[a] == position[b], "%v and %v", a.Value, b.Value)
+			if reach[a][b] && !cyclic {
+				require.True(t, position[a] < position[b], "%v before %v", a.Value, b.Value)
+			}
+		}
+	}
+}
+
+func BenchmarkNewConnection(b *testing.B) {
+	g := New()
+	nodes := make([]*Node, 5000)
+	for i := range nodes {
+		nodes[i] = g.NewNode(i)
+	}
+	for i := 1; i < len(nodes); i++ {
+		require.NoError(b, g.NewConnection(nodes[i-1], nodes[i]))
+	}
+	g.Sorted()
+
+	var (
+		source = g.NewNode("source")
+		last   = nodes[len(nodes)-1]
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, g.NewConnection(source, last))
+		require.NoError(b, g.RemoveConnection(source, last))
+	}
+}
+
+func BenchmarkRemoveNode(b *testing.B) {
+	g := New()
+	nodes := make([]*Node, 5000)
+	for i := range nodes {
+		nodes[i] = g.NewNode(i)
+	}
+	for i := 1; i < len(nodes); i++ {
+		require.NoError(b, g.NewConnection(nodes[i-1], nodes[i]))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := g.NewNode("leaf")
+		require.NoError(b, g.NewConnection(nodes[0], n))
+		require.NoError(b, g.RemoveNode(n))
+	}
+}
+
+func order(g *Graph) []string {
+	var path []string
+	for _, c := range g.Sorted() {
+		for _, n := range c {
+			path = append(path, n.Value.(string))
+		}
+	}
+	return path
+}
+
+func components(g *Graph) [][]string {
+	var path [][]string
+	for _, c := range g.Sorted() {
+		var names []string
+		for _, n := range c {
+			names = append(names, n.Value.(string))
+		}
+		path = append(path, names)
+	}
+	return path
+}
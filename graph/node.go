@@ -7,8 +7,9 @@ import (
 // Node is a member of a Graph
 type Node struct {
 	graph           *Graph
-	idx             int
-	searchState     searchState
+	prev, next      *Node
+	component       *component
+	mark            int
 	outputs, inputs []connection
 	Value           interface{}
 }
@@ -90,13 +91,6 @@ func reverseNodes(list []*Node) {
 	}
 }
 
-type searchState int
-
-const (
-	unseen searchState = iota
-	seen
-)
-
 type connection struct {
 	start, end *Node
 }
@@ -72,6 +72,28 @@ func (e *Environment) UnsetSymbol(symbol string) error {
 	return nil
 }
 
+// Snapshot returns a copy of the symbols defined in the current context. No parent Environments are included.
+func (e *Environment) Snapshot() map[string]interface{} {
+	e.RLock()
+	defer e.RUnlock()
+	symbols := make(map[string]interface{}, len(e.symbols))
+	for k, v := range e.symbols {
+		symbols[k] = v
+	}
+	return symbols
+}
+
+// Restore replaces the symbols defined in the current context with those of a Snapshot.
+func (e *Environment) Restore(snapshot map[string]interface{}) {
+	symbols := make(map[string]interface{}, len(snapshot))
+	for k, v := range snapshot {
+		symbols[k] = v
+	}
+	e.Lock()
+	defer e.Unlock()
+	e.symbols = symbols
+}
+
 // GetSymbol performs a symbol lookup and returns the value if its present. If the symbol cannot be found in the current
 // Environment context, it advances to the parent to see if can be found there.
 func (e *Environment) GetSymbol(symbol string) (interface{}, error) {
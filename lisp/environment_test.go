@@ -45,6 +45,22 @@ func TestEnvironment_Unset(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestEnvironment_SnapshotRestore(t *testing.T) {
+	env := NewEnvironment()
+	require.NoError(t, env.DefineSymbol("hello", 42))
+	snapshot := env.Snapshot()
+
+	require.NoError(t, env.SetSymbol("hello", 41))
+	require.NoError(t, env.DefineSymbol("world", 1))
+	env.Restore(snapshot)
+
+	v, err := env.GetSymbol("hello")
+	require.NoError(t, err)
+	require.Equal(t, 42, v)
+	_, err = env.GetSymbol("world")
+	require.Error(t, err)
+}
+
 func TestEnvironment_UnsetUndefined(t *testing.T) {
 	env := NewEnvironment()
 	err := env.DefineSymbol("hello", 42)
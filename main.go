@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"math/rand"
@@ -12,8 +13,10 @@ import (
 	"syscall"
 
 	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/engine/pipe"
 	"github.com/brettbuddin/shaden/engine/portaudio"
 	"github.com/brettbuddin/shaden/engine/stdout"
+	"github.com/brettbuddin/shaden/engine/wav"
 	"github.com/brettbuddin/shaden/errors"
 	"github.com/brettbuddin/shaden/midi"
 	"github.com/brettbuddin/shaden/runtime"
@@ -33,10 +36,15 @@ func main() {
 }
 
 func run(cfg Config) error {
+	// Units derive their random sources from the engine seed; this only seeds lisp built-ins.
 	rand.Seed(cfg.Seed)
 
 	var (
-		backend engine.Backend
+		backend  engine.Backend
+		recorder interface{ Record() }
+		rendered <-chan struct{}
+		realtime bool
+		output   io.Closer
 
 		logger = log.New(os.Stdout, "", 0)
 	)
@@ -78,6 +86,8 @@ func run(cfg Config) error {
 			cfg.DeviceLatency,
 			cfg.DeviceFrameSize,
 			int(cfg.SampleRate),
+			cfg.InputChannels,
+			cfg.OutputChannels,
 		)
 		if err != nil {
 			return errors.Wrap(err, "creating portaudio backend")
@@ -86,20 +96,77 @@ func run(cfg Config) error {
 		printPreamble(paBackend, cfg.Seed)
 
 		backend = paBackend
+		// Sorting in the background keeps large edits from causing dropouts. Offline backends sort within the callback
+		// so their output doesn't depend on how quickly the graph is sorted.
+		realtime = true
 	case backendStdout:
 		logger = log.New(os.Stderr, "", 0)
-		backend = stdout.New(os.Stdout, cfg.FrameSize, int(cfg.SampleRate))
+		format, err := stdout.ParseFormat(cfg.Format)
+		if err != nil {
+			return err
+		}
+		stdoutOpts := []stdout.Option{stdout.WithFormat(format)}
+		if cfg.Dither {
+			stdoutOpts = append(stdoutOpts, stdout.WithDither())
+		}
+		backend = stdout.New(os.Stdout, cfg.FrameSize, int(cfg.SampleRate), cfg.OutputChannels, stdoutOpts...)
+	case backendWAV:
+		f, err := os.Create(cfg.OutPath)
+		if err != nil {
+			return errors.Wrap(err, "creating wav file")
+		}
+		defer f.Close()
+		output = f
+
+		wavRender, err := wav.New(f, cfg.FrameSize, int(cfg.SampleRate), cfg.OutputChannels, cfg.BitDepth, cfg.Duration)
+		if err != nil {
+			return errors.Wrap(err, "creating wav backend")
+		}
+		rendered = wavRender.Done()
+		recorder = wavRender
+		backend = wavRender
+	case backendPipe:
+		logger = log.New(os.Stderr, "", 0)
+
+		in := os.Stdin
+		if cfg.InPath != "" {
+			f, err := os.Open(cfg.InPath)
+			if err != nil {
+				return errors.Wrap(err, "opening input file")
+			}
+			defer f.Close()
+			in = f
+		}
+
+		pipeBackend, err := pipe.New(in, os.Stdout, cfg.FrameSize, int(cfg.SampleRate), cfg.InputChannels, cfg.OutputChannels)
+		if err != nil {
+			return errors.Wrap(err, "creating pipe backend")
+		}
+		rendered = pipeBackend.Done()
+		recorder = pipeBackend
+		backend = pipeBackend
 	default:
 		return errors.Errorf("unknown backend %q", cfg.Backend)
 	}
 
 	opts := []engine.Option{
 		engine.WithFadeIn(cfg.FadeIn),
+		engine.WithCrossfade(cfg.Crossfade),
 		engine.WithGain(dbToFloat(cfg.Gain)),
+		engine.WithSeed(cfg.Seed),
+		engine.WithInputChannels(cfg.InputChannels),
+		engine.WithOutputChannels(cfg.OutputChannels),
+		engine.WithWorkers(cfg.Workers),
+	}
+	if cfg.ProfileWindow > 0 {
+		opts = append(opts, engine.WithProfiling(cfg.ProfileWindow))
 	}
 	if cfg.SingleSampleDisabled {
 		opts = append(opts, engine.WithSingleSampleDisabled())
 	}
+	if realtime {
+		opts = append(opts, engine.WithBackgroundSort())
+	}
 	e, err := engine.New(backend, cfg.FrameSize, opts...)
 	if err != nil {
 		return errors.Wrap(err, "engine create failed")
@@ -110,15 +177,22 @@ func run(cfg Config) error {
 	if err != nil {
 		return errors.Wrap(err, "start lisp runtime failed")
 	}
+	run.SetTransactional(cfg.Transactional)
 
 	// Start the HTTP server
-	go func() {
-		mux := http.NewServeMux()
-		runtime.AddHandler(mux, run)
-		if err := http.ListenAndServe(cfg.HTTPAddr, mux); err != nil {
-			logger.Fatal(err)
-		}
-	}()
+	if cfg.HTTPEnabled {
+		go func() {
+			mux := http.NewServeMux()
+			runtime.AddHandler(mux, run)
+			runtime.AddStatsHandler(mux, e)
+			runtime.AddProfileHandler(mux, e)
+			runtime.AddGraphHandler(mux, run)
+			runtime.AddUndoHandler(mux, run)
+			if err := http.ListenAndServe(cfg.HTTPAddr, mux); err != nil {
+				logger.Fatal(err)
+			}
+		}()
+	}
 
 	// Start the engine
 	go e.Run()
@@ -127,7 +201,15 @@ func run(cfg Config) error {
 			logger.Println("engine error:", err)
 		}
 	}()
-	defer e.Stop()
+	var stopped bool
+	stop := func() error {
+		if stopped {
+			return nil
+		}
+		stopped = true
+		return e.Stop()
+	}
+	defer stop()
 
 	if cfg.ScriptPath != "" {
 		if err := run.Load(cfg.ScriptPath); err != nil {
@@ -135,6 +217,11 @@ func run(cfg Config) error {
 		}
 	}
 
+	// Begin rendering once the patch has been loaded
+	if recorder != nil {
+		recorder.Record()
+	}
+
 	replDone := make(chan struct{})
 	if cfg.REPL {
 		go run.REPL(replDone)
@@ -143,13 +230,23 @@ func run(cfg Config) error {
 	select {
 	case <-replDone:
 	case <-waitForSignal():
+	case <-rendered:
 	}
 
+	// Failing to write rendered audio is only reported once the engine (and its backend) has stopped.
+	if err := stop(); err != nil {
+		return errors.Wrap(err, "stopping engine")
+	}
+	if output != nil {
+		if err := output.Close(); err != nil {
+			return errors.Wrap(err, "closing output file")
+		}
+	}
 	return nil
 }
 
 func waitForSignal() <-chan struct{} {
-	sigs := make(chan os.Signal)
+	sigs := make(chan os.Signal, 1)
 	done := make(chan struct{})
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
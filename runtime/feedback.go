@@ -0,0 +1,82 @@
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/lisp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+const (
+	nameFeedback    = "feedback"
+	nameGraphCycles = "graph-cycles"
+)
+
+// FeedbackLoops returns the feedback loops in the graph.
+func (r *Runtime) FeedbackLoops() ([]engine.FeedbackLoop, error) {
+	msg := engine.NewMessage(engine.FeedbackLoops)
+	if err := r.tx.Engine.SendMessage(msg); err != nil {
+		return nil, err
+	}
+	reply := <-msg.Reply
+	if reply.Error != nil {
+		return nil, reply.Error
+	}
+	return reply.Data.([]engine.FeedbackLoop), nil
+}
+
+// graphCycles lists the IDs of the units along each feedback loop in the graph.
+func (r *Runtime) graphCycles(args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError(nameGraphCycles, 0)
+	}
+	loops, err := r.FeedbackLoops()
+	if err != nil {
+		return nil, err
+	}
+	cycles := lisp.List{}
+	for _, loop := range loops {
+		ids := make(lisp.List, len(loop))
+		for i, u := range loop {
+			ids[i] = u.ID
+		}
+		cycles = append(cycles, ids)
+	}
+	return cycles, nil
+}
+
+// feedbackFn marks an output reference to be patched through a one block delay.
+func feedbackFn(args lisp.List) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, exactArgCountError(nameFeedback, 1)
+	}
+	ref, ok := args[0].(unit.OutRef)
+	if !ok {
+		return nil, typeError(nameFeedback, "output reference", 1)
+	}
+	return unit.FeedbackRef{OutRef: ref}, nil
+}
+
+// logFeedbackLoops logs the feedback loops found among the results of actions. Each loop is logged once.
+func logFeedbackLoops(logger *log.Logger, results ...interface{}) {
+	seen := map[string]bool{}
+	for _, data := range results {
+		if c, ok := data.(engine.Change); ok {
+			data = c.Data
+		}
+		loop, ok := data.(engine.FeedbackLoop)
+		if !ok || seen[loop.String()] {
+			continue
+		}
+		seen[loop.String()] = true
+
+		var b bytes.Buffer
+		fmt.Fprintln(&b, bold("Feedback loop"))
+		fmt.Fprintf(&b, "│ %s\n", loop)
+		fmt.Fprintf(&b, "└ Processed a sample at a time; patch one of its connections with (%s ...) to delay it by a block", nameFeedback)
+		logger.Print(b.String())
+	}
+}
@@ -0,0 +1,60 @@
+package runtime
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/lisp"
+)
+
+func TestGraphCycles(t *testing.T) {
+	for _, transactional := range []bool{false, true} {
+		eng, err := engine.New(newRunningBackend(), frameSize)
+		require.NoError(t, err)
+		go eng.Run()
+
+		var logged bytes.Buffer
+		run, err := New(eng, log.New(&logged, "", -1))
+		require.NoError(t, err)
+		run.SetTransactional(transactional)
+
+		_, err = run.Eval([]byte(`
+			(define a (unit/noop))
+			(define b (unit/noop))
+			(define c (unit/noop))
+			(-> a (table :x (<- b)))
+			(-> b (table :x (<- a)))
+			(-> c (table :x (<- b)))
+		`))
+		require.NoError(t, err)
+		require.Contains(t, logged.String(), "Feedback loop")
+
+		v, err := run.Eval([]byte(`(graph-cycles)`))
+		require.NoError(t, err)
+		cycles := v.(lisp.List)
+		require.Len(t, cycles, 1)
+		require.ElementsMatch(t, lisp.List{"noop-0", "noop-1"}, cycles[0])
+
+		// Delaying a connection along the loop breaks it.
+		logged.Reset()
+		_, err = run.Eval([]byte(`(-> b (table :x (feedback (<- a))))`))
+		require.NoError(t, err)
+		require.NotContains(t, logged.String(), "Feedback loop")
+		v, err = run.Eval([]byte(`(graph-cycles)`))
+		require.NoError(t, err)
+		require.Empty(t, v)
+
+		d, err := run.Graph()
+		require.NoError(t, err)
+		require.Contains(t, d.Connections, engine.Connection{From: "noop-0", Output: "out", To: "noop-1", Input: "x", Delayed: true})
+
+		_, err = run.Eval([]byte(`(feedback 1)`))
+		require.Error(t, err)
+
+		eng.Stop()
+	}
+}
@@ -1,9 +1,14 @@
 package runtime
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brettbuddin/shaden/engine"
 )
 
 // Evaler evaluates script content sent via HTTP.
@@ -11,6 +16,27 @@ type Evaler interface {
 	Eval([]byte) (interface{}, error)
 }
 
+// StatsProvider provides measurements of the processing load of the engine.
+type StatsProvider interface {
+	Stats() engine.Stats
+}
+
+// Profiler provides the processing time spent in each unit of the engine.
+type Profiler interface {
+	Profile() (engine.Profile, error)
+}
+
+// GraphDescriber describes the units of the engine and how they're connected.
+type GraphDescriber interface {
+	Graph() (engine.GraphDescription, error)
+}
+
+// Undoer undoes and redoes the changes made to the graph.
+type Undoer interface {
+	Undo() error
+	Redo() error
+}
+
 // ServeMux is a mux abstraction.
 type ServeMux interface {
 	Handle(string, http.Handler)
@@ -35,3 +61,101 @@ func AddHandler(mux ServeMux, evaler Evaler) {
 		fmt.Fprintf(w, "OK")
 	}))
 }
+
+// AddStatsHandler registers a handler with a ServeMux that reports engine load statistics as JSON.
+func AddStatsHandler(mux ServeMux, p StatsProvider) {
+	mux.Handle("/stats", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(p.Stats()); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+}
+
+// AddProfileHandler registers a handler with a ServeMux that reports the processing time spent in each unit as a
+// table sorted by descending time. Passing "by=type" aggregates the table by unit type.
+func AddProfileHandler(mux ServeMux, p Profiler) {
+	mux.Handle("/profile/units", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		profile, err := p.Profile()
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "%s", err)
+			return
+		}
+
+		fmt.Fprintf(w, "window: %s, total: %s\n\n", profile.Window.Round(time.Millisecond), profile.Total)
+
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		if r.URL.Query().Get("by") == "type" {
+			fmt.Fprintln(tw, "TYPE\tTIME\tSHARE")
+			for _, e := range profile.Types {
+				fmt.Fprintf(tw, "%s\t%s\t%.1f%%\n", e.Type, e.Time.Round(time.Microsecond), e.Share*100)
+			}
+		} else {
+			fmt.Fprintln(tw, "ID\tTYPE\tTIME\tSHARE")
+			for _, e := range profile.Units {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f%%\n", e.ID, e.Type, e.Time.Round(time.Microsecond), e.Share*100)
+			}
+		}
+		tw.Flush()
+	}))
+}
+
+// AddGraphHandler registers a handler with a ServeMux that describes the units of the engine and their connections as
+// JSON. Passing "format=dot" describes them as a Graphviz DOT digraph instead.
+func AddGraphHandler(mux ServeMux, d GraphDescriber) {
+	mux.Handle("/graph", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		graph, err := d.Graph()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "%s", err)
+			return
+		}
+		switch r.URL.Query().Get("format") {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			err = graph.WriteJSON(w)
+		case "dot":
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			err = graph.WriteDOT(w)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "unknown format %q", r.URL.Query().Get("format"))
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+}
+
+// AddUndoHandler registers handlers with a ServeMux that undo and redo the changes made to the graph by evaluations.
+func AddUndoHandler(mux ServeMux, u Undoer) {
+	handle := func(fn func() error) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusNotImplemented)
+				return
+			}
+			if err := fn(); err != nil {
+				fmt.Fprintf(w, "%s", err)
+				return
+			}
+			fmt.Fprintf(w, "OK")
+		})
+	}
+	mux.Handle("/undo", handle(u.Undo))
+	mux.Handle("/redo", handle(u.Redo))
+}
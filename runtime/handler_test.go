@@ -2,11 +2,15 @@ package runtime
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/brettbuddin/shaden/engine"
 	"github.com/brettbuddin/shaden/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -94,6 +98,200 @@ func TestHandler_UnimplementedMethod(t *testing.T) {
 	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
 }
 
+func TestHandler_Stats(t *testing.T) {
+	var (
+		mux   = http.NewServeMux()
+		stats = engine.Stats{Callbacks: 10, Overruns: 2, Load: 0.5, PeakLoad: 1.5}
+	)
+
+	AddStatsHandler(mux, statsProvider(stats))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/stats")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual engine.Stats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+	require.Equal(t, stats, actual)
+
+	resp, err = s.Client().Post(s.URL+"/stats", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+func TestHandler_Profile(t *testing.T) {
+	var (
+		mux     = http.NewServeMux()
+		profile = engine.Profile{
+			Window: time.Second,
+			Total:  3 * time.Millisecond,
+			Units: []engine.ProfileEntry{
+				{ID: "reverb-0", Type: "reverb", Time: 2 * time.Millisecond, Share: 2.0 / 3},
+				{ID: "mix-0", Type: "mix", Time: time.Millisecond, Share: 1.0 / 3},
+			},
+			Types: []engine.ProfileEntry{
+				{Type: "reverb", Time: 2 * time.Millisecond, Share: 2.0 / 3},
+				{Type: "mix", Time: time.Millisecond, Share: 1.0 / 3},
+			},
+		}
+	)
+
+	AddProfileHandler(mux, profiler{profile: profile})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/profile/units")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 5)
+	require.Equal(t, "window: 1s, total: 3ms", lines[0])
+	require.Equal(t, []string{"ID", "TYPE", "TIME", "SHARE"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{"reverb-0", "reverb", "2ms", "66.7%"}, strings.Fields(lines[3]))
+	require.Equal(t, []string{"mix-0", "mix", "1ms", "33.3%"}, strings.Fields(lines[4]))
+
+	resp, err = s.Client().Get(s.URL + "/profile/units?by=type")
+	require.NoError(t, err)
+	body, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	lines = strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 5)
+	require.Equal(t, []string{"TYPE", "TIME", "SHARE"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{"reverb", "2ms", "66.7%"}, strings.Fields(lines[3]))
+}
+
+func TestHandler_ProfileDisabled(t *testing.T) {
+	mux := http.NewServeMux()
+	AddProfileHandler(mux, profiler{err: errors.New("profiling is not enabled")})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/profile/units")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestHandler_Graph(t *testing.T) {
+	var (
+		mux   = http.NewServeMux()
+		level = 0.5
+		graph = engine.GraphDescription{
+			Units: []engine.UnitDescription{
+				{ID: "gen-0", Type: "gen", Inputs: []engine.InputDescription{}, Outputs: []string{"sine"}},
+				{
+					ID:      "mult-0",
+					Type:    "mult",
+					Inputs:  []engine.InputDescription{{Name: "x"}, {Name: "y", Value: &level}},
+					Outputs: []string{"out"},
+				},
+			},
+			Connections: []engine.Connection{{From: "gen-0", Output: "sine", To: "mult-0", Input: "x"}},
+		}
+	)
+
+	AddGraphHandler(mux, graphDescriber{graph: graph})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/graph")
+	require.NoError(t, err)
+	var actual engine.GraphDescription
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, graph, actual)
+
+	resp, err = s.Client().Get(s.URL + "/graph?format=dot")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), `"gen-0":"out-sine" -> "mult-0":"in-x";`)
+
+	resp, err = s.Client().Get(s.URL + "/graph?format=svg")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHandler_Undo(t *testing.T) {
+	var (
+		mux = http.NewServeMux()
+		u   undoer
+	)
+
+	AddUndoHandler(mux, &u)
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	post := func(path string) string {
+		resp, err := s.Client().Post(s.URL+path, "text/plain", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return string(body)
+	}
+
+	require.Equal(t, "OK", post("/undo"))
+	require.Equal(t, "OK", post("/redo"))
+	require.Equal(t, []string{"undo", "redo"}, u.calls)
+
+	u.err = errors.New("nothing to undo")
+	require.Equal(t, "nothing to undo", post("/undo"))
+
+	resp, err := s.Client().Get(s.URL + "/undo")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+type undoer struct {
+	calls []string
+	err   error
+}
+
+func (u *undoer) Undo() error {
+	u.calls = append(u.calls, "undo")
+	return u.err
+}
+
+func (u *undoer) Redo() error {
+	u.calls = append(u.calls, "redo")
+	return u.err
+}
+
+type graphDescriber struct {
+	graph engine.GraphDescription
+	err   error
+}
+
+func (d graphDescriber) Graph() (engine.GraphDescription, error) { return d.graph, d.err }
+
+type profiler struct {
+	profile engine.Profile
+	err     error
+}
+
+func (p profiler) Profile() (engine.Profile, error) { return p.profile, p.err }
+
+type statsProvider engine.Stats
+
+func (p statsProvider) Stats() engine.Stats { return engine.Stats(p) }
+
 type evaler struct {
 	content []byte
 	val     interface{}
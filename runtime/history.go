@@ -0,0 +1,275 @@
+package runtime
+
+import (
+	"sync"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/errors"
+	"github.com/brettbuddin/shaden/lisp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+const (
+	nameUndo = "undo"
+	nameRedo = "redo"
+
+	// historyLimit is the number of evaluations that can be undone.
+	historyLimit = 100
+)
+
+// history records the changes made to the graph by each evaluation, so they can be undone and redone. The changes of
+// an evaluation are undone together.
+type history struct {
+	mutex      sync.Mutex
+	group      []engine.Change
+	undo, redo [][]engine.Change
+	owners     map[*unit.Unit]*lazyUnit
+
+	// forgotten holds the Units of changes that have been discarded. Units removed from the graph are only detached
+	// while a change can still mount them again; they're closed once they're no longer referred to (see release).
+	forgotten []*unit.Unit
+}
+
+func newHistory() *history {
+	return &history{owners: map[*unit.Unit]*lazyUnit{}}
+}
+
+// record adds a change to the evaluation being recorded.
+func (h *history) record(c engine.Change) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.group = append(h.group, c)
+}
+
+// own registers the lazyUnit of a Unit; its mount state is kept in step with the changes that are undone or redone.
+func (h *history) own(lazy *lazyUnit) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.owners[lazy.created] = lazy
+}
+
+// end completes the evaluation being recorded. Recording a new change discards the changes that could be redone.
+func (h *history) end() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.group) == 0 {
+		return
+	}
+	h.undo = append(h.undo, h.group)
+	if len(h.undo) > historyLimit {
+		h.forget(h.undo[:len(h.undo)-historyLimit]...)
+		h.undo = h.undo[len(h.undo)-historyLimit:]
+	}
+	h.forget(h.redo...)
+	h.redo = nil
+	h.group = nil
+}
+
+// discard drops the changes of the evaluation being recorded.
+func (h *history) discard() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.forget(h.group)
+	h.group = nil
+}
+
+// reset forgets all changes; returning a function that restores them.
+func (h *history) reset() func() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	undo, redo, owners := h.undo, h.redo, h.owners
+	h.forget(h.undo...)
+	h.forget(h.redo...)
+	h.forget(h.group)
+	h.undo, h.redo, h.group = nil, nil, nil
+	h.owners = map[*unit.Unit]*lazyUnit{}
+	return func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		h.undo, h.redo, h.owners = undo, redo, owners
+	}
+}
+
+// forget adds the Units of groups of changes that are being discarded to those that may be closed. The mutex must be
+// held.
+func (h *history) forget(groups ...[]engine.Change) {
+	for _, group := range groups {
+		for _, c := range group {
+			h.forgotten = append(h.forgotten, c.Units()...)
+		}
+	}
+}
+
+// release returns the Units of forgotten changes that no remaining change refers to.
+func (h *history) release() []*unit.Unit {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.forgotten) == 0 {
+		return nil
+	}
+
+	referenced := map[*unit.Unit]bool{}
+	for _, group := range append(append([][]engine.Change{h.group}, h.undo...), h.redo...) {
+		for _, c := range group {
+			for _, u := range c.Units() {
+				referenced[u] = true
+			}
+		}
+	}
+	var units []*unit.Unit
+	for _, u := range h.forgotten {
+		if !referenced[u] {
+			referenced[u] = true
+			units = append(units, u)
+		}
+	}
+	h.forgotten = nil
+	return units
+}
+
+func (h *history) pop(redo bool) ([]engine.Change, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	stack := &h.undo
+	if redo {
+		stack = &h.redo
+	}
+	if len(*stack) == 0 {
+		return nil, false
+	}
+	group := (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+	return group, true
+}
+
+func (h *history) push(group []engine.Change, redo bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if redo {
+		h.redo = append(h.redo, group)
+	} else {
+		h.undo = append(h.undo, group)
+	}
+}
+
+// restored updates the mount state of the lazyUnits affected by a group of changes once it's been undone or redone;
+// returning a function that reverts them. When undoing, changes are visited in reverse; so the state from before the
+// first change wins.
+func (h *history) restored(group []engine.Change, redone bool) func() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	previous := map[*lazyUnit]bool{}
+	for i := range group {
+		c := group[i]
+		if !redone {
+			c = group[len(group)-1-i]
+		}
+		for _, u := range c.Units() {
+			lazy, ok := h.owners[u]
+			if !ok {
+				continue
+			}
+			if _, ok := previous[lazy]; !ok {
+				previous[lazy] = lazy.mount
+			}
+			before, after := c.Mounted(u)
+			lazy.mount = before
+			if redone {
+				lazy.mount = after
+			}
+		}
+	}
+	return func() {
+		for lazy, mount := range previous {
+			lazy.mount = mount
+		}
+	}
+}
+
+// onChange records the result of an engine.Undoable action issued on behalf of a lazyUnit, which may be nil, and
+// returns the result of the wrapped action. While a transaction is open the changes are recorded once it's committed.
+func onChange(e Engine, data interface{}, lazy *lazyUnit) interface{} {
+	t, ok := e.(*transactor)
+	if !ok {
+		if c, ok := data.(engine.Change); ok {
+			return c.Data
+		}
+		return data
+	}
+	if lazy != nil {
+		t.history.own(lazy)
+	}
+	if c, ok := data.(engine.Change); ok {
+		t.history.record(c)
+		return c.Data
+	}
+	return data
+}
+
+// Undo reverts the changes made to the graph by the most recent evaluation.
+func (r *Runtime) Undo() error {
+	r.txMutex.Lock()
+	defer r.txMutex.Unlock()
+	return r.rewind(false)
+}
+
+// Redo reapplies the changes reverted by the most recent undo.
+func (r *Runtime) Redo() error {
+	r.txMutex.Lock()
+	defer r.txMutex.Unlock()
+	return r.rewind(true)
+}
+
+func (r *Runtime) undo(args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError(nameUndo, 0)
+	}
+	return nil, r.rewind(false)
+}
+
+func (r *Runtime) redo(args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError(nameRedo, 0)
+	}
+	return nil, r.rewind(true)
+}
+
+// rewind undoes, or redoes, a group of changes. Within a transaction, the changes are applied along with the rest of
+// the transaction once it's committed. If the changes can't be restored, those that were are reverted.
+func (r *Runtime) rewind(redo bool) error {
+	h := r.tx.history
+	group, ok := h.pop(redo)
+	if !ok {
+		if redo {
+			return errors.New("nothing to redo")
+		}
+		return errors.New("nothing to undo")
+	}
+
+	actions := make([]interface{}, len(group))
+	for i, c := range group {
+		if redo {
+			actions[i] = engine.Undoable(c.Redo(), c.Units()...)
+		} else {
+			actions[len(group)-1-i] = engine.Undoable(c.Undo(), c.Units()...)
+		}
+	}
+	msg := engine.NewMessage(engine.Batch(actions...))
+	if err := r.tx.sendUnrecorded(msg); err != nil {
+		h.push(group, redo)
+		return err
+	}
+	if reply := <-msg.Reply; reply.Error != nil {
+		h.push(group, redo)
+		return errors.Wrap(reply.Error, "changes could not be restored")
+	}
+	revert := h.restored(group, redo)
+	h.push(group, !redo)
+
+	onRollback(r.engine, func() {
+		h.pop(!redo)
+		h.push(group, redo)
+		revert()
+	})
+	return nil
+}
@@ -0,0 +1,145 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"log"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+func TestUndoRedo(t *testing.T) {
+	for _, transactional := range []bool{false, true} {
+		eng, err := engine.New(newRunningBackend(), frameSize)
+		require.NoError(t, err)
+		go eng.Run()
+
+		run, err := New(eng, log.New(ioutil.Discard, "", -1))
+		require.NoError(t, err)
+		run.SetTransactional(transactional)
+
+		graph := func() engine.GraphDescription {
+			d, err := run.Graph()
+			require.NoError(t, err)
+			// Remounted units are added to the end of the graph.
+			sort.Slice(d.Units, func(i, j int) bool { return d.Units[i].ID < d.Units[j].ID })
+			return d
+		}
+
+		_, err = run.Eval([]byte(`
+			(define a (unit/noop))
+			(define b (unit/noop))
+			(-> b (table :x (<- a)))
+			(emit (<- b))
+		`))
+		require.NoError(t, err)
+		patched := graph()
+		require.Len(t, patched.Units, 3)
+
+		// A single evaluation is undone as one step.
+		_, err = run.Eval([]byte(`(-> b (table :x 0.5)) (unit-unmount a)`))
+		require.NoError(t, err)
+		require.Len(t, graph().Units, 2)
+
+		require.NoError(t, run.Undo())
+		require.Equal(t, patched, graph())
+
+		require.NoError(t, run.Redo())
+		require.Len(t, graph().Units, 2)
+		require.Error(t, run.Redo())
+
+		_, err = run.Eval([]byte(`(undo)`))
+		require.NoError(t, err)
+		require.Equal(t, patched, graph())
+
+		// The runtime keeps track of whether units are mounted; patching a unit remounted by an undo works.
+		_, err = run.Eval([]byte(`(undo) (-> b (table :x 0.25))`))
+		require.NoError(t, err)
+		require.Len(t, graph().Units, 2)
+		require.Error(t, run.Redo(), "new changes discard the changes that could be redone")
+
+		// Evaluations that fail aren't recorded.
+		if transactional {
+			_, err = run.Eval([]byte(`(-> b (table :x 1)) (unknown)`))
+			require.Error(t, err)
+		}
+		require.NoError(t, run.Undo())
+		require.Len(t, graph().Units, 1)
+		require.Error(t, run.Undo())
+		require.NoError(t, run.Redo())
+		require.Len(t, graph().Units, 2)
+
+		_, err = run.Eval([]byte(`(clear)`))
+		require.NoError(t, err)
+		require.Error(t, run.Undo())
+
+		eng.Stop()
+	}
+}
+
+func TestUndoRedo_WithinFailedTransaction(t *testing.T) {
+	eng, err := engine.New(newRunningBackend(), frameSize)
+	require.NoError(t, err)
+	go eng.Run()
+	defer eng.Stop()
+
+	run, err := New(eng, log.New(ioutil.Discard, "", -1))
+	require.NoError(t, err)
+	run.SetTransactional(true)
+
+	_, err = run.Eval([]byte(`(define a (unit/noop))`))
+	require.NoError(t, err)
+	initial, err := run.Graph()
+	require.NoError(t, err)
+	_, err = run.Eval([]byte(`(emit (<- a))`))
+	require.NoError(t, err)
+	before, err := run.Graph()
+	require.NoError(t, err)
+
+	// The undo is applied with the rest of the transaction; so it's rolled back along with it.
+	_, err = run.Eval([]byte(`(undo) (unknown)`))
+	require.Error(t, err)
+	after, err := run.Graph()
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+	require.Error(t, run.Redo(), "the undo was rolled back")
+
+	// The evaluation can still be undone.
+	require.NoError(t, run.Undo())
+	after, err = run.Graph()
+	require.NoError(t, err)
+	require.Equal(t, initial, after)
+}
+
+func TestHistory_Release(t *testing.T) {
+	var (
+		h    = newHistory()
+		a, b = unit.NewUnit(unit.NewIO("a", frameSize), nil), unit.NewUnit(unit.NewIO("b", frameSize), nil)
+	)
+	change := func(u *unit.Unit) engine.Change {
+		g := engine.NewGraph(frameSize)
+		v, err := engine.Undoable(engine.MountUnit(u), u)(g)
+		require.NoError(t, err)
+		return v.(engine.Change)
+	}
+
+	h.record(change(a))
+	h.end()
+	h.record(change(b))
+	h.end()
+	require.Empty(t, h.release())
+
+	// Units are released once no change refers to them.
+	undone, _ := h.pop(false)
+	h.push(undone, true)
+	h.record(change(a))
+	h.end()
+	require.Equal(t, []*unit.Unit{b}, h.release())
+
+	h.reset()
+	require.Equal(t, []*unit.Unit{a}, h.release())
+}
@@ -0,0 +1,218 @@
+package runtime
+
+import (
+	"sort"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/errors"
+	"github.com/brettbuddin/shaden/lisp"
+	"github.com/brettbuddin/shaden/unit"
+)
+
+const (
+	nameSnapshot        = "snapshot"
+	namePresetStore     = "preset-store"
+	namePreset          = "preset"
+	namePresets         = "presets"
+	namePresetRecall    = "preset-recall"
+	namePresetMorph     = "preset-morph"
+	namePresetMorphStop = "preset-morph-stop"
+)
+
+func (r *Runtime) loadPresets(env *lisp.Environment) {
+	env.DefineSymbol(nameSnapshot, r.snapshot)
+	env.DefineSymbol(namePresetStore, r.presetStore)
+	env.DefineSymbol(namePreset, r.preset)
+	env.DefineSymbol(namePresets, r.presetNames)
+	env.DefineSymbol(namePresetRecall, r.presetRecall)
+	env.DefineSymbol(namePresetMorph, r.presetMorph)
+	env.DefineSymbol(namePresetMorphStop, r.presetMorphStop)
+}
+
+// snapshot captures the constant input values of the given units; or of every unit in the graph if none are given.
+// Changes collected by an open transaction aren't captured.
+func (r *Runtime) snapshot(args lisp.List) (interface{}, error) {
+	if len(args) == 1 {
+		if l, ok := args[0].(lisp.List); ok {
+			args = l
+		}
+	}
+	units := make([]*unit.Unit, len(args))
+	for i, arg := range args {
+		lazy, ok := arg.(*lazyUnit)
+		if !ok {
+			return nil, typeError(nameSnapshot, "unit", i+1)
+		}
+		units[i] = lazy.created
+	}
+
+	msg := engine.NewMessage(engine.TakeSnapshot(units...))
+	if err := r.tx.Engine.SendMessage(msg); err != nil {
+		return nil, err
+	}
+	reply := <-msg.Reply
+	if reply.Error != nil {
+		return nil, reply.Error
+	}
+	return reply.Data, nil
+}
+
+func (r *Runtime) presetStore(args lisp.List) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, exactArgCountError(namePresetStore, 2)
+	}
+	name, ok := presetName(args[0])
+	if !ok {
+		return nil, typeError(namePresetStore, "string or keyword", 1)
+	}
+	s, ok := args[1].(engine.Snapshot)
+	if !ok {
+		return nil, typeError(namePresetStore, "snapshot", 2)
+	}
+
+	r.presetsMutex.Lock()
+	prev, existed := r.presets[name]
+	r.presets[name] = s
+	r.presetsMutex.Unlock()
+
+	onRollback(r.engine, func() {
+		r.presetsMutex.Lock()
+		defer r.presetsMutex.Unlock()
+		if existed {
+			r.presets[name] = prev
+		} else {
+			delete(r.presets, name)
+		}
+	})
+	return s, nil
+}
+
+func (r *Runtime) preset(args lisp.List) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, exactArgCountError(namePreset, 1)
+	}
+	return r.resolveSnapshot(namePreset, args[0], 1)
+}
+
+func (r *Runtime) presetNames(args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError(namePresets, 0)
+	}
+	r.presetsMutex.Lock()
+	names := make([]string, 0, len(r.presets))
+	for name := range r.presets {
+		names = append(names, name)
+	}
+	r.presetsMutex.Unlock()
+
+	sort.Strings(names)
+	list := make(lisp.List, len(names))
+	for i, name := range names {
+		list[i] = name
+	}
+	return list, nil
+}
+
+func (r *Runtime) presetRecall(args lisp.List) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, exactArgCountError(namePresetRecall, 1)
+	}
+	s, err := r.resolveSnapshot(namePresetRecall, args[0], 1)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.send(engine.RecallSnapshot(s))
+}
+
+// presetMorph morphs between two presets. The third argument decides how: a number sets the position between them
+// immediately, a duration (ms) morphs from the first to the second over that time and an output follows the position
+// given by a control signal.
+func (r *Runtime) presetMorph(args lisp.List) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, exactArgCountError(namePresetMorph, 3)
+	}
+	a, err := r.resolveSnapshot(namePresetMorph, args[0], 1)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.resolveSnapshot(namePresetMorph, args[1], 2)
+	if err != nil {
+		return nil, err
+	}
+
+	var action func(*engine.Graph) (interface{}, error)
+	switch v := args[2].(type) {
+	case int:
+		action = engine.MorphSnapshots(a, b, float64(v))
+	case float64:
+		action = engine.MorphSnapshots(a, b, v)
+	case dsp.MS:
+		action = engine.MorphSnapshotsOver(a, b, int(v.Float64()))
+	case unit.OutRef:
+		action = engine.MorphSnapshotsBy(a, b, v)
+	default:
+		return nil, typeError(namePresetMorph, "number, duration or output", 3)
+	}
+	return nil, r.send(action)
+}
+
+func (r *Runtime) presetMorphStop(args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError(namePresetMorphStop, 0)
+	}
+	return nil, r.send(engine.StopMorph)
+}
+
+// resolveSnapshot returns the snapshot given as an argument; either directly or by the name of a stored preset.
+func (r *Runtime) resolveSnapshot(fn string, arg interface{}, pos int) (engine.Snapshot, error) {
+	if s, ok := arg.(engine.Snapshot); ok {
+		return s, nil
+	}
+	name, ok := presetName(arg)
+	if !ok {
+		return engine.Snapshot{}, typeError(fn, "snapshot or preset name", pos)
+	}
+	r.presetsMutex.Lock()
+	defer r.presetsMutex.Unlock()
+	s, ok := r.presets[name]
+	if !ok {
+		return engine.Snapshot{}, errors.Errorf("no preset named %q", name)
+	}
+	return s, nil
+}
+
+// clearPresets removes all stored presets; their snapshots refer to units that no longer exist once the engine has
+// been cleared.
+func (r *Runtime) clearPresets() {
+	r.presetsMutex.Lock()
+	presets := r.presets
+	r.presets = map[string]engine.Snapshot{}
+	r.presetsMutex.Unlock()
+
+	onRollback(r.engine, func() {
+		r.presetsMutex.Lock()
+		defer r.presetsMutex.Unlock()
+		r.presets = presets
+	})
+}
+
+// send sends an action to the engine and waits for it to be handled.
+func (r *Runtime) send(action interface{}) error {
+	msg := engine.NewMessage(action)
+	if err := r.engine.SendMessage(msg); err != nil {
+		return err
+	}
+	return (<-msg.Reply).Error
+}
+
+func presetName(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case lisp.Keyword:
+		return string(v), true
+	default:
+		return "", false
+	}
+}
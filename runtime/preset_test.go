@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/lisp"
+)
+
+func TestPresets(t *testing.T) {
+	eng, err := engine.New(newRunningBackend(), frameSize)
+	require.NoError(t, err)
+	go eng.Run()
+	defer eng.Stop()
+
+	run, err := New(eng, log.New(ioutil.Discard, "", -1))
+	require.NoError(t, err)
+
+	value := func(input string) float64 {
+		d, err := run.Graph()
+		require.NoError(t, err)
+		for _, u := range d.Units {
+			if u.Type != "noop" {
+				continue
+			}
+			for _, in := range u.Inputs {
+				if in.Name == input {
+					return *in.Value
+				}
+			}
+		}
+		t.Fatalf("no input %q", input)
+		return 0
+	}
+
+	_, err = run.Eval([]byte(`
+		(define noop (unit/noop))
+		(-> noop (table :x 1))
+		(preset-store :quiet (snapshot))
+		(-> noop (table :x 3))
+		(preset-store "loud" (snapshot noop))
+	`))
+	require.NoError(t, err)
+
+	v, err := run.Eval([]byte(`(presets)`))
+	require.NoError(t, err)
+	require.Equal(t, lisp.List{"loud", "quiet"}, v)
+
+	_, err = run.Eval([]byte(`(preset-recall :quiet)`))
+	require.NoError(t, err)
+	require.Equal(t, 1.0, value("x"))
+
+	_, err = run.Eval([]byte(`(preset-morph :quiet :loud 0.5)`))
+	require.NoError(t, err)
+	require.Equal(t, 2.0, value("x"))
+
+	_, err = run.Eval([]byte(`(preset-recall (preset "loud"))`))
+	require.NoError(t, err)
+	require.Equal(t, 3.0, value("x"))
+
+	_, err = run.Eval([]byte(`(preset-morph :quiet :loud (ms 1))`))
+	require.NoError(t, err)
+	_, err = run.Eval([]byte(`(preset-morph-stop)`))
+	require.NoError(t, err)
+
+	_, err = run.Eval([]byte(`(preset-recall :missing)`))
+	require.Error(t, err)
+	_, err = run.Eval([]byte(`(preset-morph :quiet :loud "fast")`))
+	require.Error(t, err)
+
+	_, err = run.Eval([]byte(`(clear)`))
+	require.NoError(t, err)
+	v, err = run.Eval([]byte(`(presets)`))
+	require.NoError(t, err)
+	require.Empty(t, v)
+}
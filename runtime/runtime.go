@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
+	"time"
 
 	prompt "github.com/c-bata/go-prompt"
 
@@ -22,6 +24,10 @@ type Engine interface {
 	UnitBuilders() map[string]unit.Builder
 	FrameSize() int
 	SampleRate() int
+	Seed() int64
+	OutputChannels() int
+	Stats() engine.Stats
+	Profile() (engine.Profile, error)
 }
 
 // Runtime represents the runtime execution environment
@@ -29,17 +35,28 @@ type Runtime struct {
 	base, user *lisp.Environment
 	engine     Engine
 	logger     *log.Logger
+
+	transactional bool
+	tx            *transactor
+	txMutex       sync.Mutex
+
+	presets      map[string]engine.Snapshot
+	presetsMutex sync.Mutex
 }
 
 // New returns a new Runtime
 func New(e Engine, logger *log.Logger) (*Runtime, error) {
 	base := lisp.NewEnvironment()
 	builtin.Load(base)
+	tx := &transactor{Engine: e, history: newHistory(), logger: logger}
 	r := &Runtime{
 		base:   base,
 		user:   base.Branch(),
-		engine: e,
+		engine: tx,
 		logger: logger,
+		tx:     tx,
+
+		presets: map[string]engine.Snapshot{},
 	}
 	if err := r.loadShaden(); err != nil {
 		return nil, err
@@ -52,6 +69,15 @@ func (r *Runtime) ClearUserspace() {
 	r.user = r.base.Branch()
 }
 
+// SetTransactional enables or disables transactional evaluation. When enabled, the changes to the engine made by an
+// evaluation are applied together only if the whole evaluation succeeds. If it fails, they're discarded and the user
+// environment is restored to its state prior to evaluation.
+func (r *Runtime) SetTransactional(enabled bool) {
+	r.txMutex.Lock()
+	defer r.txMutex.Unlock()
+	r.transactional = enabled
+}
+
 // REPL runs the REPL.
 func (r *Runtime) REPL(done chan struct{}) {
 	prompt.New(
@@ -83,7 +109,7 @@ func (r *Runtime) Eval(code []byte) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	v, err := r.user.Eval(node)
+	v, err := r.transaction(func() (interface{}, error) { return r.user.Eval(node) })
 	if err != nil {
 		return v, errors.Wrap(err, "failed to evaluating <string>")
 	}
@@ -103,7 +129,7 @@ func (r *Runtime) Load(path string) error {
 		return errors.Wrapf(err, "parsing %q", path)
 	}
 
-	if _, err := r.user.Eval(node); err != nil {
+	if _, err := r.transaction(func() (interface{}, error) { return r.user.Eval(node) }); err != nil {
 		return errors.Wrapf(err, "failed to evaluating %q", path)
 	}
 	return nil
@@ -122,6 +148,15 @@ func (r *Runtime) loadShaden() error {
 	// Engine
 	env.DefineSymbol("emit", emitFn(engine, logger))
 	env.DefineSymbol("clear", r.engineClear)
+	env.DefineSymbol("engine-stats", r.engineStats)
+	env.DefineSymbol("engine-profile", r.engineProfile)
+	env.DefineSymbol("graph-dot", r.graphDOT)
+	env.DefineSymbol("graph-json", r.graphJSON)
+	env.DefineSymbol(nameGraphCycles, r.graphCycles)
+	env.DefineSymbol(namePatchSave, r.patchSave)
+	r.loadPresets(env)
+	env.DefineSymbol(nameUndo, r.undo)
+	env.DefineSymbol(nameRedo, r.redo)
 
 	// Units
 	if err := createBuilders(env, engine, logger); err != nil {
@@ -136,6 +171,7 @@ func (r *Runtime) loadShaden() error {
 	env.DefineSymbol(nameUnitPatch, patchFn(engine, logger, true))
 	env.DefineSymbol(nameUnitPatchOnly, patchFn(engine, logger, false))
 	env.DefineSymbol(nameUnitOutput, outFn(engine))
+	env.DefineSymbol(nameFeedback, feedbackFn)
 
 	return nil
 }
@@ -198,6 +234,104 @@ func (r *Runtime) loadConstants(env *lisp.Environment) {
 	env.DefineSymbol("mode/average", 1)
 }
 
+// Graph describes the units mounted in the engine and how they're connected. Changes collected by an open transaction
+// aren't included until it's committed.
+func (r *Runtime) Graph() (engine.GraphDescription, error) {
+	msg := engine.NewMessage(engine.DescribeGraph)
+	if err := r.tx.Engine.SendMessage(msg); err != nil {
+		return engine.GraphDescription{}, err
+	}
+	reply := <-msg.Reply
+	if reply.Error != nil {
+		return engine.GraphDescription{}, reply.Error
+	}
+	return reply.Data.(engine.GraphDescription), nil
+}
+
+func (r *Runtime) graphDOT(_ *lisp.Environment, args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError("graph-dot", 0)
+	}
+	d, err := r.Graph()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := d.WriteDOT(&buf); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+func (r *Runtime) graphJSON(_ *lisp.Environment, args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError("graph-json", 0)
+	}
+	d, err := r.Graph()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := d.WriteJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+func (r *Runtime) engineStats(_ *lisp.Environment, args lisp.List) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, exactArgCountError("engine-stats", 0)
+	}
+	stats := r.engine.Stats()
+	return lisp.Table{
+		lisp.Keyword("budget"):       float64(stats.Budget) / float64(time.Millisecond),
+		lisp.Keyword("callbacks"):    int(stats.Callbacks),
+		lisp.Keyword("overruns"):     int(stats.Overruns),
+		lisp.Keyword("load"):         stats.Load,
+		lisp.Keyword("average-load"): stats.AverageLoad,
+		lisp.Keyword("peak-load"):    stats.PeakLoad,
+	}, nil
+}
+
+func (r *Runtime) engineProfile(_ *lisp.Environment, args lisp.List) (interface{}, error) {
+	if len(args) > 1 {
+		return nil, errors.New("engine-profile expects 0 or 1 arguments")
+	}
+	byType := false
+	if len(args) == 1 {
+		switch args[0] {
+		case lisp.Keyword("unit"):
+		case lisp.Keyword("type"):
+			byType = true
+		default:
+			return nil, errors.New("engine-profile expects :unit or :type for argument 1")
+		}
+	}
+
+	profile, err := r.engine.Profile()
+	if err != nil {
+		return nil, err
+	}
+	entries := profile.Units
+	if byType {
+		entries = profile.Types
+	}
+
+	list := make(lisp.List, len(entries))
+	for i, entry := range entries {
+		t := lisp.Table{
+			lisp.Keyword("type"):  entry.Type,
+			lisp.Keyword("time"):  float64(entry.Time) / float64(time.Millisecond),
+			lisp.Keyword("share"): entry.Share,
+		}
+		if !byType {
+			t[lisp.Keyword("id")] = entry.ID
+		}
+		list[i] = t
+	}
+	return list, nil
+}
+
 func (r *Runtime) engineClear(*lisp.Environment, lisp.List) (interface{}, error) {
 	msg := engine.NewMessage(engine.Clear)
 	if err := r.engine.SendMessage(msg); err != nil {
@@ -208,5 +342,7 @@ func (r *Runtime) engineClear(*lisp.Environment, lisp.List) (interface{}, error)
 		return nil, reply.Error
 	}
 	r.ClearUserspace()
+	r.clearPresets()
+	r.tx.forgetHistory()
 	return nil, nil
 }
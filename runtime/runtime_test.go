@@ -1,12 +1,14 @@
 package runtime
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/lisp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -21,7 +23,7 @@ func TestEnvironmentClearing(t *testing.T) {
 	var (
 		be       = newBackend(1) // Execute the callback once
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -56,7 +58,7 @@ func TestEmitting(t *testing.T) {
 	var (
 		be       = newBackend(3) // Execute the callback twice
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -87,3 +89,85 @@ func TestEmitting(t *testing.T) {
 		t.Error("timeout waiting for completion")
 	}
 }
+
+func TestGraphExport(t *testing.T) {
+	var (
+		be       = newBackend(5) // Mount, patch, emit and two descriptions
+		messages = messageChannel{make(chan *engine.Message)}
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
+		logger   = log.New(os.Stdout, "", -1)
+	)
+
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		run, err := New(eng, logger)
+		require.NoError(t, err)
+		_, err = run.Eval([]byte(`
+			(define noop (unit/noop))
+			(-> noop (table :x 0.5))
+			(emit (<- noop))
+		`))
+		assert.NoError(t, err)
+
+		v, err := run.Eval([]byte(`(graph-json)`))
+		assert.NoError(t, err)
+		var graph engine.GraphDescription
+		assert.NoError(t, json.Unmarshal([]byte(v.(string)), &graph))
+		assert.Len(t, graph.Units, 2)
+		assert.Len(t, graph.Connections, 2)
+
+		v, err = run.Eval([]byte(`(graph-dot)`))
+		assert.NoError(t, err)
+		assert.Contains(t, v, "digraph shaden {")
+		assert.Contains(t, v, "x: 0.5")
+
+		_, err = run.Eval([]byte(`(graph-dot 1)`))
+		assert.Error(t, err)
+		require.NoError(t, eng.Stop())
+	}()
+
+	go func() {
+		eng.Run()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Error("timeout waiting for completion")
+	}
+}
+
+func TestEngineStats(t *testing.T) {
+	eng, err := engine.New(newBackend(0), frameSize)
+	require.NoError(t, err)
+
+	run, err := New(eng, log.New(os.Stdout, "", -1))
+	require.NoError(t, err)
+
+	v, err := run.Eval([]byte(`(engine-stats)`))
+	require.NoError(t, err)
+	stats, ok := v.(lisp.Table)
+	require.True(t, ok)
+	require.Equal(t, 0, stats[lisp.Keyword("callbacks")])
+	require.Equal(t, 0, stats[lisp.Keyword("overruns")])
+	require.InDelta(t, 5.8, stats[lisp.Keyword("budget")], 0.1)
+
+	_, err = run.Eval([]byte(`(engine-stats 1)`))
+	require.Error(t, err)
+}
+
+func TestEngineProfile(t *testing.T) {
+	eng, err := engine.New(newBackend(0), frameSize)
+	require.NoError(t, err)
+
+	run, err := New(eng, log.New(os.Stdout, "", -1))
+	require.NoError(t, err)
+
+	_, err = run.Eval([]byte(`(engine-profile)`))
+	require.Error(t, err)
+	_, err = run.Eval([]byte(`(engine-profile :bogus)`))
+	require.Error(t, err)
+}
@@ -0,0 +1,302 @@
+package runtime
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/errors"
+	"github.com/brettbuddin/shaden/lisp"
+)
+
+const (
+	namePatchSave = "patch-save"
+
+	typeSink = "sink"
+)
+
+// SavePatch writes a lisp script that recreates the units mounted in the engine: their configuration, the values of
+// their inputs and properties, how they're connected and which outputs are emitted. Loading the script clears the
+// engine first.
+func (r *Runtime) SavePatch(w io.Writer) error {
+	d, err := r.Graph()
+	if err != nil {
+		return err
+	}
+	return writePatch(w, d)
+}
+
+func (r *Runtime) patchSave(args lisp.List) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, exactArgCountError(namePatchSave, 1)
+	}
+	path, ok := args[0].(string)
+	if !ok {
+		return nil, typeError(namePatchSave, "string", 1)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.SavePatch(f); err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "saving patch to %q", path)
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	r.logger.Printf(bold("Saved patch to %s\n"), path)
+	return nil, nil
+}
+
+// writePatch writes the lisp script that recreates a graph. Values that can't be expressed in lisp are left out; a
+// comment notes each of them.
+func writePatch(w io.Writer, d engine.GraphDescription) error {
+	var (
+		bw      = bufio.NewWriter(w)
+		sink    *engine.UnitDescription
+		units   []engine.UnitDescription
+		sources = map[string]map[string]engine.Connection{}
+	)
+	for i, u := range d.Units {
+		if u.Type == typeSink {
+			sink = &d.Units[i]
+			continue
+		}
+		units = append(units, u)
+	}
+	for _, c := range d.Connections {
+		if sources[c.To] == nil {
+			sources[c.To] = map[string]engine.Connection{}
+		}
+		sources[c.To][c.Input] = c
+	}
+
+	fmt.Fprintln(bw, "; Patch saved by shaden")
+	fmt.Fprintln(bw, "(clear)")
+	fmt.Fprintln(bw)
+
+	for _, u := range units {
+		config := ""
+		if len(u.Config) > 0 {
+			table, skipped := formatTable(u.Config)
+			writeSkipped(bw, u.ID, "config", skipped)
+			config = " " + table
+		}
+		fmt.Fprintf(bw, "(define %s (unit/%s%s))\n", u.ID, u.Type, config)
+	}
+	if len(units) > 0 {
+		fmt.Fprintln(bw)
+	}
+
+	for _, u := range units {
+		var entries []string
+		for _, in := range u.Inputs {
+			if c, ok := sources[u.ID][in.Name]; ok {
+				entries = append(entries, formatKey(in.Name)+" "+formatOutRef(c))
+				continue
+			}
+			if in.Constant == nil || in.Normal {
+				continue
+			}
+			entries = append(entries, formatKey(in.Name)+" "+formatValuer(in.Constant))
+		}
+		props := make([]string, 0, len(u.Props))
+		for k := range u.Props {
+			props = append(props, k)
+		}
+		sort.Strings(props)
+		for _, k := range props {
+			v, err := formatValue(u.Props[k])
+			if err != nil {
+				writeSkipped(bw, u.ID, "property", []string{k})
+				continue
+			}
+			entries = append(entries, formatKey(k)+" "+v)
+		}
+		fmt.Fprintf(bw, "(-> %s (table%s))\n", u.ID, joinEntries(entries))
+	}
+
+	if sink != nil {
+		writeEmit(bw, *sink, sources[sink.ID])
+	}
+	return bw.Flush()
+}
+
+// writeEmit writes the emit expression that routes outputs to the channels of the sink. Outputs emitted to every
+// channel are emitted once.
+func writeEmit(w io.Writer, sink engine.UnitDescription, sources map[string]engine.Connection) {
+	var refs []string
+	for i := range sink.Inputs {
+		c, ok := sources[strconv.Itoa(i)]
+		if !ok {
+			break
+		}
+		refs = append(refs, formatOutRef(c))
+	}
+	if len(refs) == 0 {
+		return
+	}
+	if len(refs) < len(sources) {
+		fmt.Fprintf(w, "; channels following channel %d are emitted after an unused channel and can't be saved\n", len(refs)-1)
+	}
+
+	all := len(refs) == len(sink.Inputs)
+	for _, ref := range refs {
+		all = all && ref == refs[0]
+	}
+	if all {
+		refs = refs[:1]
+	}
+	fmt.Fprintf(w, "\n(emit %s)\n", strings.Join(refs, " "))
+}
+
+func writeSkipped(w io.Writer, id, kind string, names []string) {
+	for _, name := range names {
+		fmt.Fprintf(w, "; %s: %s %q can't be expressed in lisp and isn't saved\n", id, kind, name)
+	}
+}
+
+func joinEntries(entries []string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	return " " + strings.Join(entries, " ")
+}
+
+func formatOutRef(c engine.Connection) string {
+	ref := fmt.Sprintf("(<- %s %s)", c.From, formatKey(c.Output))
+	if c.Output == "out" {
+		ref = fmt.Sprintf("(<- %s)", c.From)
+	}
+	if c.Delayed {
+		return fmt.Sprintf("(%s %s)", nameFeedback, ref)
+	}
+	return ref
+}
+
+// formatValuer formats a constant input value; retaining the unit it was expressed in.
+func formatValuer(v dsp.Valuer) string {
+	switch v := v.(type) {
+	case dsp.Hz:
+		return fmt.Sprintf("(hz %s)", formatFloat(v.Raw))
+	case dsp.Pitch:
+		return fmt.Sprintf("(hz %q)", v.Raw)
+	case dsp.MS:
+		return fmt.Sprintf("(ms %s)", formatFloat(v.Raw))
+	case dsp.BeatsPerMin:
+		return fmt.Sprintf("(bpm %s)", formatFloat(v.Raw))
+	default:
+		return formatFloat(v.Float64())
+	}
+}
+
+// formatValue formats a configuration or property value.
+func formatValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "nil", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", errors.Errorf("%v has no lisp representation", v)
+		}
+		return formatFloat(v), nil
+	case string:
+		if strings.ContainsAny(v, `"\`) {
+			return "", errors.Errorf("string %q has no lisp representation", v)
+		}
+		return `"` + v + `"`, nil
+	case lisp.Keyword:
+		return ":" + string(v), nil
+	case dsp.Valuer:
+		return formatValuer(v), nil
+	case lisp.List:
+		return formatList(v)
+	case []interface{}:
+		return formatList(v)
+	case lisp.Table:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = e
+		}
+		table, skipped := formatTable(m)
+		if len(skipped) > 0 {
+			return "", errors.Errorf("table has values with no lisp representation")
+		}
+		return table, nil
+	default:
+		return "", errors.Errorf("%T has no lisp representation", v)
+	}
+}
+
+func formatList(l []interface{}) (string, error) {
+	items := make([]string, len(l))
+	for i, e := range l {
+		s, err := formatValue(e)
+		if err != nil {
+			return "", err
+		}
+		items[i] = s
+	}
+	return "(list" + joinEntries(items) + ")", nil
+}
+
+// formatTable formats a table; returning the keys of values that were left out.
+func formatTable(m map[string]interface{}) (string, []string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var entries, skipped []string
+	for _, k := range keys {
+		v, err := formatValue(m[k])
+		if err != nil {
+			skipped = append(skipped, k)
+			continue
+		}
+		entries = append(entries, formatKey(k)+" "+v)
+	}
+	return "(table" + joinEntries(entries) + ")", skipped
+}
+
+// formatKey formats the name of an input, output or property as a keyword; or as a string if it can't be read back as
+// one.
+func formatKey(name string) string {
+	if name == "" {
+		return `""`
+	}
+	for _, r := range name {
+		if !isKeywordRune(r) {
+			return strconv.Quote(name)
+		}
+	}
+	return ":" + name
+}
+
+func isKeywordRune(r rune) bool {
+	return strings.ContainsRune("><=-+*&_@^~:.%/!?#", r) || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// formatFloat formats a float so that it's read back as a float rather than an integer.
+func formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
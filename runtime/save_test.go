@@ -0,0 +1,174 @@
+package runtime
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/lisp"
+)
+
+func TestWritePatch(t *testing.T) {
+	var (
+		level = 0.75
+		d     = engine.GraphDescription{
+			Units: []engine.UnitDescription{
+				{
+					ID:     "sink-0",
+					Type:   "sink",
+					Inputs: []engine.InputDescription{{Name: "0"}, {Name: "1"}},
+				},
+				{
+					ID:   "gen-0",
+					Type: "gen",
+					Inputs: []engine.InputDescription{
+						{Name: "amp", Constant: dsp.Float64(1), Normal: true},
+						{Name: "freq", Constant: dsp.Frequency(220, sampleRate)},
+						{Name: "phase-mod"},
+					},
+					Outputs: []string{"saw", "sine"},
+				},
+				{
+					ID:     "mix-0",
+					Type:   "mix",
+					Config: map[string]interface{}{"size": 2},
+					Inputs: []engine.InputDescription{
+						{Name: "0/in"},
+						{Name: "0/level", Value: &level, Constant: dsp.Float64(level)},
+						{Name: "1/in"},
+						{Name: "1/level", Constant: dsp.Duration(10, sampleRate)},
+					},
+					Outputs: []string{"out"},
+					Props: map[string]interface{}{
+						"mode":      lisp.Keyword("sum"),
+						"intervals": struct{}{},
+					},
+				},
+			},
+			Connections: []engine.Connection{
+				{From: "mix-0", Output: "out", To: "sink-0", Input: "0"},
+				{From: "mix-0", Output: "out", To: "sink-0", Input: "1"},
+				{From: "gen-0", Output: "sine", To: "mix-0", Input: "0/in"},
+				{From: "gen-0", Output: "saw", To: "mix-0", Input: "1/in"},
+				{From: "mix-0", Output: "out", To: "gen-0", Input: "phase-mod", Delayed: true},
+			},
+		}
+		buf bytes.Buffer
+	)
+
+	require.NoError(t, writePatch(&buf, d))
+	require.Equal(t, `; Patch saved by shaden
+(clear)
+
+(define gen-0 (unit/gen))
+(define mix-0 (unit/mix (table :size 2)))
+
+(-> gen-0 (table :freq (hz 220.0) :phase-mod (feedback (<- mix-0))))
+; mix-0: property "intervals" can't be expressed in lisp and isn't saved
+(-> mix-0 (table :0/in (<- gen-0 :sine) :0/level 0.75 :1/in (<- gen-0 :saw) :1/level (ms 10.0) :mode :sum))
+
+(emit (<- mix-0))
+`, buf.String())
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{1, "1"},
+		{1.0, "1.0"},
+		{-0.25, "-0.25"},
+		{true, "true"},
+		{"sine", `"sine"`},
+		{lisp.Keyword("sine"), ":sine"},
+		{lisp.List{1, "a"}, `(list 1 "a")`},
+		{lisp.Table{lisp.Keyword("b"): 2, "a": 1.5}, "(table :a 1.5 :b 2)"},
+		{dsp.BPM(120, sampleRate), "(bpm 120.0)"},
+	}
+	for _, test := range tests {
+		actual, err := formatValue(test.value)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, actual)
+	}
+
+	_, err := formatValue(`"quoted"`)
+	require.Error(t, err)
+	_, err = formatValue(struct{}{})
+	require.Error(t, err)
+}
+
+func TestPatchSave_RoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shaden")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "patch.lisp")
+
+	saved := func() engine.GraphDescription {
+		eng, err := engine.New(newRunningBackend(), frameSize)
+		require.NoError(t, err)
+		go eng.Run()
+		defer eng.Stop()
+
+		run, err := New(eng, log.New(ioutil.Discard, "", -1))
+		require.NoError(t, err)
+		_, err = run.Eval([]byte(`
+			(define osc (unit/gen))
+			(define env (unit/adsr))
+			(define mix (unit/mix (table :size 2)))
+			(-> osc (table :freq (hz "C4") :amp 0.5))
+			(-> env (table :attack (ms 5) :decay (ms 250.5)))
+			(-> mix (list (table :in (<- osc :sine) :level (<- env))
+			              (table :in (<- osc :saw) :level 0.25)))
+			(=> osc (table :phase-mod (feedback (<- mix))))
+			(emit (<- mix) (<- osc :triangle))
+		`))
+		require.NoError(t, err)
+		_, err = run.Eval([]byte(`(patch-save "` + path + `")`))
+		require.NoError(t, err)
+
+		d, err := run.Graph()
+		require.NoError(t, err)
+		return d
+	}()
+
+	eng, err := engine.New(newRunningBackend(), frameSize)
+	require.NoError(t, err)
+	go eng.Run()
+	defer eng.Stop()
+
+	run, err := New(eng, log.New(ioutil.Discard, "", -1))
+	require.NoError(t, err)
+	require.NoError(t, run.Load(path))
+	loaded, err := run.Graph()
+	require.NoError(t, err)
+
+	// The sink is created by the engine rather than the patch; so its ID differs. Units referred to by delayed
+	// connections are mounted as soon as the connection is patched; so units may be mounted in a different order.
+	byID := func(units []engine.UnitDescription) {
+		sort.Slice(units, func(i, j int) bool { return units[i].ID < units[j].ID })
+	}
+	byID(saved.Units[1:])
+	byID(loaded.Units[1:])
+	require.Equal(t, saved.Units[1:], loaded.Units[1:])
+	for i := range saved.Connections {
+		saved.Connections[i].To = normalizeSink(saved.Connections[i].To, saved)
+		loaded.Connections[i].To = normalizeSink(loaded.Connections[i].To, loaded)
+	}
+	require.ElementsMatch(t, saved.Connections, loaded.Connections)
+}
+
+func normalizeSink(id string, d engine.GraphDescription) string {
+	if id == d.Units[0].ID {
+		return "sink"
+	}
+	return id
+}
@@ -34,11 +34,11 @@ func (b *backend) read(i, j int) float32 {
 	return b.written[i][j]
 }
 
-func (b *backend) Start(cb func([]float32, [][]float32)) error {
+func (b *backend) Start(cb func([][]float32, [][]float32)) error {
 	b.Lock()
 	defer b.Unlock()
 	var (
-		in  = make([]float32, frameSize)
+		in  = [][]float32{make([]float32, frameSize)}
 		out = [][]float32{
 			make([]float32, frameSize),
 			make([]float32, frameSize),
@@ -72,3 +72,37 @@ func (c messageChannel) Receive() *engine.Message {
 }
 
 func (c messageChannel) Close() { close(c.messages) }
+
+// runningBackend calls the callback continuously from its own goroutine until it's stopped.
+type runningBackend struct {
+	done chan struct{}
+}
+
+func newRunningBackend() *runningBackend {
+	return &runningBackend{done: make(chan struct{})}
+}
+
+func (b *runningBackend) Start(cb func([][]float32, [][]float32)) error {
+	var (
+		in  = [][]float32{make([]float32, frameSize)}
+		out = [][]float32{
+			make([]float32, frameSize),
+			make([]float32, frameSize),
+		}
+	)
+	go func() {
+		for {
+			select {
+			case <-b.done:
+				return
+			default:
+				cb(in, out)
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+	return nil
+}
+func (b *runningBackend) Stop() error   { close(b.done); return nil }
+func (*runningBackend) FrameSize() int  { return frameSize }
+func (*runningBackend) SampleRate() int { return sampleRate }
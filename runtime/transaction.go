@@ -0,0 +1,198 @@
+package runtime
+
+import (
+	"log"
+	"sync"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/errors"
+)
+
+// transactor sits between the runtime and the Engine. While a transaction is open, messages are collected instead of
+// being sent; they are applied together as a single engine.Batch when the transaction is committed.
+type transactor struct {
+	Engine
+
+	mutex    sync.Mutex
+	open     bool
+	actions  []interface{}
+	rollback []func()
+
+	history    *history
+	recordFrom int
+	unrecorded map[int]bool
+	logger     *log.Logger
+}
+
+// SendMessage sends a message to the Engine, or collects its action if a transaction is open. Collected messages are
+// replied to immediately.
+func (t *transactor) SendMessage(msg *engine.Message) error {
+	return t.send(msg, true)
+}
+
+// sendUnrecorded sends a message like SendMessage; but if it's collected by a transaction, the changes it makes aren't
+// recorded in the history once the transaction is committed.
+func (t *transactor) sendUnrecorded(msg *engine.Message) error {
+	return t.send(msg, false)
+}
+
+func (t *transactor) send(msg *engine.Message, record bool) error {
+	t.mutex.Lock()
+	if !t.open {
+		t.mutex.Unlock()
+		return t.Engine.SendMessage(msg)
+	}
+	if !record {
+		t.unrecorded[len(t.actions)] = true
+	}
+	t.actions = append(t.actions, msg.Action)
+	t.mutex.Unlock()
+
+	if msg.Reply != nil {
+		msg.Reply <- &engine.Reply{}
+	}
+	return nil
+}
+
+func (t *transactor) begin() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.open = true
+	t.actions = nil
+	t.rollback = nil
+	t.recordFrom = 0
+	t.unrecorded = map[int]bool{}
+}
+
+// onRollback registers a function that undoes a change made while the transaction is open.
+func (t *transactor) onRollback(fn func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.open {
+		t.rollback = append(t.rollback, fn)
+	}
+}
+
+// commit applies all collected actions within a single audio callback. The graph actions of the runtime are Undoable,
+// and a graph cleared within the batch is kept until it has been applied; if an action fails, the changes of those
+// applied before it are undone within the same callback (see engine.Batch), leaving the graph as it was before the
+// transaction. Otherwise, the feedback loops formed by the actions are logged.
+func (t *transactor) commit() error {
+	t.mutex.Lock()
+	actions, recordFrom, unrecorded := t.actions, t.recordFrom, t.unrecorded
+	t.open = false
+	t.mutex.Unlock()
+
+	if len(actions) == 0 {
+		return nil
+	}
+	msg := engine.NewMessage(engine.Batch(actions...))
+	if err := t.Engine.SendMessage(msg); err != nil {
+		return err
+	}
+	reply := <-msg.Reply
+	if reply.Error != nil {
+		return reply.Error
+	}
+	if results, ok := reply.Data.([]interface{}); ok {
+		for i := recordFrom; i < len(results); i++ {
+			if c, ok := results[i].(engine.Change); ok && !unrecorded[i] {
+				t.history.record(c)
+			}
+		}
+		logFeedbackLoops(t.logger, results...)
+	}
+	return nil
+}
+
+// abort discards all collected actions and undoes the changes registered with onRollback.
+func (t *transactor) abort() {
+	t.mutex.Lock()
+	rollback := t.rollback
+	t.open = false
+	t.actions = nil
+	t.rollback = nil
+	t.mutex.Unlock()
+
+	for i := len(rollback) - 1; i >= 0; i-- {
+		rollback[i]()
+	}
+}
+
+// forgetHistory discards the recorded history; including the changes collected so far by an open transaction.
+func (t *transactor) forgetHistory() {
+	t.mutex.Lock()
+	if t.open {
+		t.recordFrom = len(t.actions)
+	}
+	t.mutex.Unlock()
+
+	t.onRollback(t.history.reset())
+}
+
+// onRollback registers a function to be called if the transaction open on an Engine is rolled back.
+func onRollback(e Engine, fn func()) {
+	if t, ok := e.(*transactor); ok {
+		t.onRollback(fn)
+	}
+}
+
+// transaction evaluates fn within a transaction when transactional evaluation is enabled. Engine actions are only
+// applied if fn succeeds; otherwise they are discarded and the user environment is restored. The changes fn makes to
+// the graph are recorded in the history as a single step.
+func (r *Runtime) transaction(fn func() (interface{}, error)) (interface{}, error) {
+	r.txMutex.Lock()
+	defer r.txMutex.Unlock()
+	if !r.transactional {
+		defer r.endHistory()
+		return fn()
+	}
+
+	var (
+		user     = r.user
+		snapshot = user.Snapshot()
+	)
+	r.tx.begin()
+
+	rollback := func() {
+		r.tx.history.discard()
+		r.tx.abort()
+		r.closeForgotten()
+		user.Restore(snapshot)
+		r.user = user
+	}
+
+	v, err := fn()
+	if err != nil {
+		rollback()
+		return v, errors.Wrap(err, "transaction rolled back")
+	}
+	if err := r.tx.commit(); err != nil {
+		rollback()
+		return nil, errors.Wrap(err, "transaction commit failed")
+	}
+	r.endHistory()
+	return v, nil
+}
+
+// endHistory completes the evaluation being recorded in the history.
+func (r *Runtime) endHistory() {
+	r.tx.history.end()
+	r.closeForgotten()
+}
+
+// closeForgotten closes the Units, detached from the graph, that the history no longer refers to.
+func (r *Runtime) closeForgotten() {
+	units := r.tx.history.release()
+	if len(units) == 0 {
+		return
+	}
+	msg := engine.NewMessage(engine.CloseDetachedUnits(units...))
+	if err := r.tx.Engine.SendMessage(msg); err != nil {
+		r.logger.Printf("closing units: %s", err)
+		return
+	}
+	if reply := <-msg.Reply; reply.Error != nil {
+		r.logger.Printf("closing units: %s", reply.Error)
+	}
+}
@@ -0,0 +1,220 @@
+package runtime
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/engine"
+	"github.com/brettbuddin/shaden/lisp"
+)
+
+func TestTransaction_Commit(t *testing.T) {
+	var (
+		eng = newRecordingEngine(t)
+		run = newTransactionalRuntime(t, eng)
+	)
+
+	_, err := run.Eval([]byte(`
+		(define noop (unit/noop))
+		(-> noop (table :x 1))
+		(emit (<- noop))
+	`))
+	require.NoError(t, err)
+	require.Len(t, eng.actions, 1)
+	require.IsType(t, engine.Batch(), eng.actions[0])
+
+	_, err = run.Eval([]byte(`noop`))
+	require.NoError(t, err)
+}
+
+func TestTransaction_Rollback(t *testing.T) {
+	var (
+		eng = newRecordingEngine(t)
+		run = newTransactionalRuntime(t, eng)
+	)
+
+	_, err := run.Eval([]byte(`(define existing 1)`))
+	require.NoError(t, err)
+
+	_, err = run.Eval([]byte(`
+		(define noop (unit/noop))
+		(-> noop (table :x 1))
+		(set existing 2)
+		(-> noop (table :missing 1))
+	`))
+	require.Error(t, err)
+	require.Empty(t, eng.actions)
+
+	_, err = run.Eval([]byte(`noop`))
+	require.Error(t, err)
+	v, err := run.Eval([]byte(`existing`))
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+}
+
+func TestTransaction_CommitFailure(t *testing.T) {
+	var (
+		eng = newRecordingEngine(t)
+		run = newTransactionalRuntime(t, eng)
+	)
+
+	_, err := run.Eval([]byte(`(define noop (unit/noop))`))
+	require.NoError(t, err)
+
+	eng.err = errors.New("exploded")
+	_, err = run.Eval([]byte(`(-> noop (table :x 1))`))
+	require.Error(t, err)
+	require.Len(t, eng.actions, 1)
+
+	// The unit is mounted again on the next attempt.
+	eng.err = nil
+	eng.actions = nil
+	_, err = run.Eval([]byte(`(-> noop (table :x 1))`))
+	require.NoError(t, err)
+	require.Len(t, eng.actions, 1)
+}
+
+func TestTransaction_CommitFailureRollsBackGraph(t *testing.T) {
+	eng, err := engine.New(newRunningBackend(), frameSize)
+	require.NoError(t, err)
+	go eng.Run()
+	defer eng.Stop()
+
+	run := newTransactionalRuntime(t, eng)
+	_, err = run.Eval([]byte(`
+		(define a (unit/noop))
+		(define b (unit/noop))
+		(-> b (table :x (<- a)))
+		(emit (<- b))
+	`))
+	require.NoError(t, err)
+	before, err := run.Graph()
+	require.NoError(t, err)
+
+	// An action that fails once the batch is applied, after the actions collected by the evaluation.
+	node, err := lisp.Parse(bytes.NewBufferString(`
+		(define c (unit/noop))
+		(-> c (table :x (<- b)))
+		(-> b (table :x 0.5))
+		(emit (<- c))
+	`))
+	require.NoError(t, err)
+	_, err = run.transaction(func() (interface{}, error) {
+		if _, err := run.user.Eval(node); err != nil {
+			return nil, err
+		}
+		return nil, run.send(func(*engine.Graph) (interface{}, error) {
+			return nil, errors.New("exploded")
+		})
+	})
+	require.Error(t, err)
+
+	after, err := run.Graph()
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	// The units of the failed transaction can be mounted by the next one.
+	_, err = run.Eval([]byte(`
+		(define c (unit/noop))
+		(-> c (table :x (<- b)))
+	`))
+	require.NoError(t, err)
+	after, err = run.Graph()
+	require.NoError(t, err)
+	require.Len(t, after.Units, len(before.Units)+1)
+}
+
+func TestTransaction_CommitFailureRestoresClearedGraph(t *testing.T) {
+	eng, err := engine.New(newRunningBackend(), frameSize)
+	require.NoError(t, err)
+	go eng.Run()
+	defer eng.Stop()
+
+	run := newTransactionalRuntime(t, eng)
+	_, err = run.Eval([]byte(`
+		(define a (unit/noop))
+		(define b (unit/noop))
+		(-> b (table :x (<- a)))
+		(emit (<- b))
+	`))
+	require.NoError(t, err)
+	before, err := run.Graph()
+	require.NoError(t, err)
+
+	node, err := lisp.Parse(bytes.NewBufferString(`
+		(clear)
+		(define c (unit/noop))
+		(emit (<- c))
+	`))
+	require.NoError(t, err)
+	_, err = run.transaction(func() (interface{}, error) {
+		if _, err := run.user.Eval(node); err != nil {
+			return nil, err
+		}
+		return nil, run.send(func(*engine.Graph) (interface{}, error) {
+			return nil, errors.New("exploded")
+		})
+	})
+	require.Error(t, err)
+
+	after, err := run.Graph()
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	// The restored graph can still be changed.
+	_, err = run.Eval([]byte(`(define c (unit/noop)) (-> c (table :x (<- b)))`))
+	require.NoError(t, err)
+	after, err = run.Graph()
+	require.NoError(t, err)
+	require.Len(t, after.Units, len(before.Units)+1)
+}
+
+func TestTransaction_Disabled(t *testing.T) {
+	var (
+		eng    = newRecordingEngine(t)
+		run, _ = New(eng, log.New(ioutil.Discard, "", 0))
+	)
+
+	_, err := run.Eval([]byte(`
+		(define noop (unit/noop))
+		(-> noop (table :x 1))
+		(bogus)
+	`))
+	require.Error(t, err)
+	require.Len(t, eng.actions, 2)
+}
+
+func newTransactionalRuntime(t *testing.T, e Engine) *Runtime {
+	run, err := New(e, log.New(ioutil.Discard, "", 0))
+	require.NoError(t, err)
+	run.SetTransactional(true)
+	return run
+}
+
+func newRecordingEngine(t *testing.T) *recordingEngine {
+	e, err := engine.New(newBackend(0), frameSize)
+	require.NoError(t, err)
+	return &recordingEngine{Engine: e}
+}
+
+// recordingEngine records the actions of messages instead of handling them. Batches fail with err.
+type recordingEngine struct {
+	*engine.Engine
+	actions []interface{}
+	err     error
+}
+
+func (e *recordingEngine) SendMessage(msg *engine.Message) error {
+	e.actions = append(e.actions, msg.Action)
+	var err error
+	if _, ok := msg.Action.(func(*engine.Engine) (interface{}, error)); ok {
+		err = e.err
+	}
+	msg.Reply <- &engine.Reply{Error: err}
+	return nil
+}
@@ -50,7 +50,7 @@ func (r *lazyUnit) mounted() (*unit.Unit, error) {
 		return r.created, nil
 	}
 
-	m := engine.NewMessage(engine.MountUnit(r.created))
+	m := engine.NewMessage(engine.Undoable(engine.MountUnit(r.created), r.created))
 
 	if err := r.engine.SendMessage(m); err != nil {
 		return nil, err
@@ -61,6 +61,8 @@ func (r *lazyUnit) mounted() (*unit.Unit, error) {
 	}
 	r.logger.Printf("%s\n└ Completed in %s\n", bold("Adding "+r.created.ID), reply.Duration)
 	r.mount = true
+	onChange(r.engine, reply.Data, r)
+	onRollback(r.engine, func() { r.mount = false })
 	return r.created, nil
 }
 
@@ -121,6 +123,7 @@ func defineBuilders(env *lisp.Environment, builder unit.Builder, e Engine, logge
 			Values:     config,
 			SampleRate: e.SampleRate(),
 			FrameSize:  e.FrameSize(),
+			Seed:       e.Seed(),
 		})
 		if err != nil {
 			return nil, err
@@ -192,7 +195,8 @@ func unitUnmountFn(e Engine, logger *log.Logger) func(lisp.List) (interface{}, e
 			return nil, errors.Wrap(err, "retrieving mounted unit failed")
 		}
 
-		m := engine.NewMessage(engine.UnmountUnit(u))
+		// The unit is only detached; it's closed once the history no longer refers to it.
+		m := engine.NewMessage(engine.Undoable(engine.DetachUnit(u), u))
 
 		if err := e.SendMessage(m); err != nil {
 			return nil, err
@@ -203,6 +207,8 @@ func unitUnmountFn(e Engine, logger *log.Logger) func(lisp.List) (interface{}, e
 		}
 		logger.Printf(bold("Removing %s\n└ Completed in %s\n"), u.ID, reply.Duration)
 		lazy.mount = false
+		onChange(e, reply.Data, lazy)
+		onRollback(e, func() { lazy.mount = true })
 		return nil, nil
 	}
 }
@@ -227,8 +233,16 @@ func patchFn(e Engine, logger *log.Logger, forceReset bool) func(lisp.List) (int
 		if err != nil {
 			return nil, errors.Wrap(err, "retrieving mounted unit failed")
 		}
+		for k := range inputs {
+			if _, ok := u.In[k]; ok {
+				continue
+			}
+			if _, ok := u.Prop[k]; !ok {
+				return nil, errors.Errorf("unit %q has no input or property %q", u.ID, k)
+			}
+		}
 
-		m := engine.NewMessage(engine.PatchInput(u, inputs, forceReset))
+		m := engine.NewMessage(engine.Undoable(engine.PatchInput(u, inputs, forceReset), u))
 
 		if err := e.SendMessage(m); err != nil {
 			return nil, err
@@ -237,6 +251,7 @@ func patchFn(e Engine, logger *log.Logger, forceReset bool) func(lisp.List) (int
 		if reply.Error != nil {
 			return nil, reply.Error
 		}
+		loop := onChange(e, reply.Data, lazy)
 
 		names := make([]string, 0, len(inputs))
 		for k := range inputs {
@@ -253,6 +268,7 @@ func patchFn(e Engine, logger *log.Logger, forceReset bool) func(lisp.List) (int
 		tw.Flush()
 		fmt.Fprintf(&b, "└ Completed in %s\n", reply.Duration)
 		logger.Print(b.String())
+		logFeedbackLoops(logger, loop)
 
 		return lazy, nil
 	}
@@ -323,27 +339,23 @@ func patchableValue(v interface{}) interface{} {
 
 func emitFn(e Engine, logger *log.Logger) func(lisp.List) (interface{}, error) {
 	return func(args lisp.List) (interface{}, error) {
-		if len(args) < 1 || len(args) > 2 {
-			return nil, errors.Errorf("%s expects 1 or 2 arguments", nameEmit)
+		if len(args) < 1 {
+			return nil, minArgCountError(nameEmit, 1)
 		}
 
-		left, ok := args[0].(unit.OutRef)
-		if !ok {
-			return nil, typeError(nameEmit, "output reference", 1)
-		}
-
-		var right unit.OutRef
-		if len(args) > 1 {
-			var ok bool
-			right, ok = args[1].(unit.OutRef)
+		refs := make([]unit.OutRef, len(args))
+		for i, arg := range args {
+			ref, ok := arg.(unit.OutRef)
 			if !ok {
-				return nil, typeError(nameEmit, "output reference", 2)
+				return nil, typeError(nameEmit, "output reference", i+1)
 			}
-		} else {
-			right = left
+			refs[i] = ref
+		}
+		if len(refs) > e.OutputChannels() {
+			return nil, errors.Errorf("%d outputs exceeds the %d output channels", len(refs), e.OutputChannels())
 		}
 
-		msg := engine.NewMessage(engine.EmitOutputs(left, right))
+		msg := engine.NewMessage(engine.Undoable(engine.EmitOutputs(refs...)))
 		if err := e.SendMessage(msg); err != nil {
 			return nil, err
 		}
@@ -352,13 +364,21 @@ func emitFn(e Engine, logger *log.Logger) func(lisp.List) (interface{}, error) {
 		var b bytes.Buffer
 		fmt.Fprintln(&b, bold("Emitting"))
 		tw := tabwriter.NewWriter(&b, 8, 8, 1, ' ', 0)
-		fmt.Fprintf(tw, "│ %s\t-> left\n", left)
-		fmt.Fprintf(tw, "│ %s\t-> right\n", right)
+		if len(refs) == 1 {
+			fmt.Fprintf(tw, "│ %s\t-> all channels\n", refs[0])
+		} else {
+			for i, ref := range refs {
+				fmt.Fprintf(tw, "│ %s\t-> channel %d\n", ref, i)
+			}
+		}
 		tw.Flush()
 		fmt.Fprintf(&b, "└ Completed in %s", reply.Duration)
 		logger.Print(b.String())
 
-		return reply.Data, reply.Error
+		if reply.Error != nil {
+			return nil, reply.Error
+		}
+		return onChange(e, reply.Data, nil), nil
 	}
 }
 
@@ -19,7 +19,7 @@ func TestUnitOutputs(t *testing.T) {
 	var (
 		be       = newBackend(0)
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -38,7 +38,7 @@ func TestUnitInputs(t *testing.T) {
 	var (
 		be       = newBackend(0)
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -57,7 +57,7 @@ func TestUnitID(t *testing.T) {
 	var (
 		be       = newBackend(0)
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -76,7 +76,7 @@ func TestUnitType(t *testing.T) {
 	var (
 		be       = newBackend(0)
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -95,7 +95,7 @@ func TestUnitOutput(t *testing.T) {
 	var (
 		be       = newBackend(1) // execute callback once
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -132,7 +132,7 @@ func TestUnitPatch(t *testing.T) {
 	var (
 		be       = newBackend(2) // execute callback twice
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -166,7 +166,7 @@ func TestUnitUnmount(t *testing.T) {
 	var (
 		be       = newBackend(3)
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -203,7 +203,7 @@ func TestUnitRemove(t *testing.T) {
 	var (
 		be       = newBackend(3)
 		messages = messageChannel{make(chan *engine.Message)}
-		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages))
+		eng, err = engine.New(be, frameSize, engine.WithMessageChannel(messages), engine.WithMessageLimit(1))
 		logger   = log.New(os.Stdout, "", -1)
 	)
 
@@ -1128,12 +1128,12 @@ func TestAllUnits(t *testing.T) {
 				name += "_" + s.description
 			}
 			t.Run(name, func(t *testing.T) {
-				rand.Seed(1)
 				builder := builders[test.unit]
 				u, err := builder(Config{
 					Values:     test.configValues,
 					SampleRate: sampleRate,
 					FrameSize:  frameSize,
+					Rand:       rand.New(rand.NewSource(1)),
 				})
 				require.NoError(t, err)
 				s.TestUnit(t, i, u)
@@ -1,6 +1,14 @@
 package unit
 
-import "github.com/mitchellh/mapstructure"
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/brettbuddin/shaden/dsp"
+)
 
 var (
 	builders = map[string]IOBuilder{
@@ -46,14 +54,18 @@ var (
 		"decimate":           newDecimate,
 		"delay":              newDelay,
 		"demux":              newDemux,
+		"diode-ladder":       buildFilterModel(func() filterModel { return &dsp.DiodeLadder{} }),
 		"dynamics":           newDynamics,
 		"euclid":             newEuclid,
 		"filter":             newFilter,
+		"fm-operator":        newFMOperator,
+		"fm4":                newFM4,
 		"fold":               newFold,
 		"gate":               newGate,
 		"gate-mix":           newGateMix,
 		"gate-series":        newGateSeries,
 		"gen":                newGen,
+		"ladder":             buildFilterModel(func() filterModel { return &dsp.Ladder{} }),
 		"lag":                newLag,
 		"latch":              newLatch,
 		"lerp":               newInterpolate,
@@ -67,10 +79,12 @@ var (
 		"pan":                newPan,
 		"panmix":             newPanMix,
 		"pitch":              newPitch,
+		"pluck":              newPluck,
 		"quantize":           newQuantize,
 		"random-series":      newRandomSeries,
 		"rcd":                newRCD,
 		"reverb":             newReverb,
+		"sallen-key":         buildFilterModel(func() filterModel { return &dsp.SallenKey{} }),
 		"sample":             newWAVSample,
 		"shift":              newShift,
 		"slope":              newSlope,
@@ -81,6 +95,7 @@ var (
 		"transpose":          newTranspose,
 		"transpose-interval": newTransposeInterval,
 		"val-gate":           newValToGate,
+		"wavetable":          newWavetable,
 		"xfade":              newCrossfade,
 		"xfeed":              newCrossfeed,
 	}
@@ -92,10 +107,14 @@ type IOBuilder func(*IO, Config) (*Unit, error)
 // Builder constructs a Unit of some type.
 type Builder func(Config) (*Unit, error)
 
-// Config is a map that's used to provide configuration options to Builders.
+// Config is a map that's used to provide configuration options to Builders. Seed is the random seed of the engine. Rand
+// is the random source for the Unit being built; when it's not provided, Builders derive one from Seed and the ID of
+// the Unit.
 type Config struct {
 	Values                map[string]interface{}
 	SampleRate, FrameSize int
+	Seed                  int64
+	Rand                  *rand.Rand
 }
 
 // Decode loads a struct with the contents of the raw Config object.
@@ -108,25 +127,45 @@ func Builders() map[string]Builder {
 	return PrepareBuilders(builders)
 }
 
-// PrepareBuilders converts a set of IOBuilders to a set of Builders.
+// PrepareBuilders converts a set of IOBuilders to a set of Builders. Units are numbered per type in the order they are
+// built, so their IDs (and random sources) are not affected by the creation of Units of other types.
 func PrepareBuilders(builders map[string]IOBuilder) map[string]Builder {
 	m := map[string]Builder{}
 	for k, v := range builders {
 		m[k] = func(typ string, f IOBuilder) Builder {
+			var count uint32
 			return func(c Config) (*Unit, error) {
-				return f(NewIO(typ, c.FrameSize), c)
+				io := newIO(typ, atomic.AddUint32(&count, 1)-1, c.FrameSize)
+				io.Config = c.Values
+				if c.Rand == nil {
+					c.Rand = newRand(c.Seed, io.ID)
+				}
+				return f(io, c)
 			}
 		}(k, v)
 	}
 	return m
 }
 
+// newRand returns a random source derived from a seed and a Unit ID.
+func newRand(seed int64, id string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
 func buildUnary(op unaryOp) IOBuilder {
 	return func(io *IO, c Config) (*Unit, error) {
 		return newUnary(io, op)
 	}
 }
 
+func buildFilterModel(newModel func() filterModel) IOBuilder {
+	return func(io *IO, c Config) (*Unit, error) {
+		return newFilterModel(io, c, newModel())
+	}
+}
+
 func buildBinary(op binaryOp) IOBuilder {
 	return func(io *IO, c Config) (*Unit, error) {
 		return newBinary(io, op)
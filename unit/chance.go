@@ -6,8 +6,9 @@ import (
 	"github.com/brettbuddin/shaden/dsp"
 )
 
-func newChance(io *IO, _ Config) (*Unit, error) {
+func newChance(io *IO, c Config) (*Unit, error) {
 	return NewUnit(io, &chance{
+		rand: c.Rand,
 		in:   io.NewIn("in", dsp.Float64(0)),
 		bias: io.NewIn("bias", dsp.Float64(0)),
 		a:    io.NewOut("a"),
@@ -19,6 +20,7 @@ type chance struct {
 	in, bias *In
 	a, b     *Out
 	last     float64
+	rand     *rand.Rand
 }
 
 func (c *chance) ProcessSample(i int) {
@@ -34,7 +36,7 @@ func (c *chance) ProcessSample(i int) {
 		} else if bias == 0 {
 			a, b = 1, -1
 		} else {
-			if rand.Float64() > bias {
+			if c.rand.Float64() > bias {
 				a, b = 1, -1
 			} else {
 				a, b = -1, 1
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"fmt"
+
+	"github.com/brettbuddin/shaden/graph"
+)
+
+// FeedbackRef is a reference to a Unit's Out that is patched into an In through a one block delay. Connections that
+// close a feedback loop otherwise force the units along the loop to be processed a sample at a time; delaying one of
+// them lets the rest of the loop be processed a block at a time.
+type FeedbackRef struct {
+	OutRef
+}
+
+func (r FeedbackRef) String() string {
+	return fmt.Sprintf("feedback(%s)", r.OutRef)
+}
+
+// PatchFeedback connects an Out to an In through a one block delay. The Unit of the In isn't ordered after the Unit of
+// the Out; instead the delay is ordered ahead of both, so the In reads the block the Out wrote during the previous
+// callback.
+func PatchFeedback(g *graph.Graph, out Output, in *In) error {
+	o := out.Out()
+	for _, n := range []*graph.Node{o.node, in.node} {
+		if !g.Exists(n) {
+			return graph.NotInGraphError{Node: n}
+		}
+	}
+	if err := Unpatch(g, in); err != nil {
+		return err
+	}
+	f := &feedback{
+		in:    in,
+		out:   o,
+		frame: make([]float64, len(in.frame)),
+	}
+	f.node = g.NewNode(f)
+	if err := g.NewConnection(f.node, in.node); err != nil {
+		return err
+	}
+	if err := g.NewConnection(f.node, o.unit.node); err != nil {
+		return err
+	}
+	in.source = o
+	in.frame = f.frame
+	in.feedback = f
+	o.delayed = append(o.delayed, in)
+	return nil
+}
+
+// feedback copies the frame of an Out for an In to read during the following callback.
+type feedback struct {
+	in    *In
+	out   *Out
+	node  *graph.Node
+	frame []float64
+}
+
+// ProcessFrame copies the frame of the Out.
+func (f *feedback) ProcessFrame(int) {
+	copy(f.frame, f.out.frame)
+}
+
+// Unit returns the Unit of the In
+func (f *feedback) Unit() *Unit {
+	return f.in.unit
+}
+
+// remove removes the delay from a Graph and from the destinations of the Out.
+func (f *feedback) remove(g *graph.Graph) error {
+	if err := g.RemoveNode(f.node); err != nil {
+		return err
+	}
+	delayed := f.out.delayed[:0]
+	for _, in := range f.out.delayed {
+		if in != f.in {
+			delayed = append(delayed, in)
+		}
+	}
+	f.out.delayed = delayed
+	f.in.feedback = nil
+	return nil
+}
@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"math"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/errors"
+)
+
+const maxOversample = 16
+
+// filterModel is a model of an analog filter; see dsp.Ladder.
+type filterModel interface {
+	Tick(in, cutoff, resonance, drive float64) float64
+}
+
+func newFilterModel(io *IO, c Config, model filterModel) (*Unit, error) {
+	var config struct {
+		Oversample int
+	}
+	if err := c.Decode(&config); err != nil {
+		return nil, err
+	}
+
+	if config.Oversample == 0 {
+		config.Oversample = 2
+	} else if config.Oversample < 1 {
+		config.Oversample = 1
+	} else if config.Oversample > maxOversample {
+		config.Oversample = maxOversample
+	}
+	if !dsp.IsPowerOfTwo(config.Oversample) {
+		return nil, errors.Errorf("oversample %d is not a power of two", config.Oversample)
+	}
+
+	return NewUnit(io, &modeledFilter{
+		model:      model,
+		in:         io.NewIn("in", dsp.Float64(0)),
+		cutoff:     io.NewIn("cutoff", dsp.Frequency(1000, c.SampleRate)),
+		res:        io.NewIn("res", dsp.Float64(0)),
+		drive:      io.NewIn("drive", dsp.Float64(1)),
+		out:        io.NewOut("out"),
+		oversample: config.Oversample,
+		decimator:  dsp.NewDecimator(config.Oversample),
+		samples:    make([]float64, config.Oversample),
+	}), nil
+}
+
+// modeledFilter runs a filter model several times per sample, interpolating its input between samples, and decimates
+// the results back to the sample rate. Resonant models are unstable as their cutoff nears the Nyquist frequency, and
+// their saturation aliases; both are lessened by oversampling.
+type modeledFilter struct {
+	model                  filterModel
+	in, cutoff, res, drive *In
+	out                    *Out
+	oversample             int
+	decimator              *dsp.Decimator
+	samples                []float64
+	last                   float64
+}
+
+func (f *modeledFilter) ProcessSample(i int) {
+	var (
+		in     = f.in.Read(i)
+		cutoff = f.cutoff.ReadSlow(i, func(v float64) float64 { return dsp.Clamp(math.Abs(v), 0, 0.45) })
+		res    = f.res.ReadSlow(i, clamp(0, dsp.MaxResonance))
+		drive  = f.drive.Read(i)
+		n      = float64(f.oversample)
+	)
+	for j := range f.samples {
+		f.samples[j] = f.model.Tick(dsp.Lerp(f.last, in, float64(j+1)/n), cutoff/n, res, drive)
+	}
+	f.out.Write(i, f.decimator.Tick(f.samples))
+	f.last = in
+}
@@ -0,0 +1,61 @@
+package unit
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+)
+
+func TestFilterModels(t *testing.T) {
+	for _, name := range []string{"ladder", "diode-ladder", "sallen-key"} {
+		for _, oversample := range []int{1, 2, 4} {
+			u, err := Builders()[name](Config{
+				Values:     map[string]interface{}{"oversample": oversample},
+				SampleRate: sampleRate,
+				FrameSize:  frameSize,
+			})
+			require.NoError(t, err)
+
+			var (
+				in  = u.In["in"]
+				out = u.Out["out"].Out()
+			)
+			in.Fill(dsp.Float64(0.01))
+			u.In["cutoff"].Fill(dsp.Frequency(5000, sampleRate))
+			for n := 0; n < 10; n++ {
+				for i := 0; i < frameSize; i++ {
+					u.ProcessSample(i)
+				}
+			}
+			require.InDelta(t, 0.01, out.Read(frameSize-1), 1e-4, "%s: unity gain at DC", name)
+
+			// Resonance at the top of the range stays bounded, even with the cutoff near the Nyquist frequency.
+			r := rand.New(rand.NewSource(1))
+			u.In["cutoff"].Fill(dsp.Frequency(20000, sampleRate))
+			u.In["res"].Fill(dsp.Float64(2))
+			u.In["drive"].Fill(dsp.Float64(4))
+			for n := 0; n < 10; n++ {
+				for i := 0; i < frameSize; i++ {
+					in.Write(i, dsp.RandRange(r, -1, 1))
+					u.ProcessSample(i)
+					v := out.Read(i)
+					require.False(t, math.IsNaN(v), "%s: oversample %d", name, oversample)
+					require.True(t, math.Abs(v) < 4, "%s: oversample %d: %f", name, oversample, v)
+				}
+			}
+		}
+	}
+}
+
+func TestFilterModels_OversampleNotPowerOfTwo(t *testing.T) {
+	_, err := Builders()["ladder"](Config{
+		Values:     map[string]interface{}{"oversample": 3},
+		SampleRate: sampleRate,
+		FrameSize:  frameSize,
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"fmt"
+
+	"github.com/brettbuddin/shaden/dsp"
+)
+
+func newFMOperator(io *IO, c Config) (*Unit, error) {
+	return NewUnit(io, &fmOperator{
+		freq:     io.NewIn("freq", dsp.Frequency(440, c.SampleRate)),
+		pm:       io.NewIn("phase-mod", dsp.Float64(0)),
+		op:       newOperatorInputs(io, ""),
+		feedback: io.NewIn("feedback", dsp.Float64(0)),
+		out:      io.NewOut("out"),
+	}), nil
+}
+
+type fmOperator struct {
+	freq, pm, feedback *In
+	op                 operatorInputs
+	operator           operator
+	out                *Out
+}
+
+func (o *fmOperator) ProcessSample(i int) {
+	var (
+		freq     = o.freq.Read(i)
+		pm       = o.pm.Read(i)
+		feedback = o.feedback.Read(i)
+	)
+	o.out.Write(i, o.operator.tick(o.op.freq(i, freq), pm, feedback, o.op.amp(i)))
+}
+
+func newFM4(io *IO, c Config) (*Unit, error) {
+	fm := &fm4{
+		freq:      io.NewIn("freq", dsp.Frequency(440, c.SampleRate)),
+		algorithm: io.NewIn("algorithm", dsp.Float64(0)),
+		feedback:  io.NewIn("feedback", dsp.Float64(0)),
+		out:       io.NewOut("out"),
+	}
+	for i := range fm.ops {
+		fm.ops[i] = newOperatorInputs(io, fmt.Sprintf("%d/", i))
+	}
+	return NewUnit(io, fm), nil
+}
+
+// fm4 is a four operator FM voice. Operators only modulate operators with a lower index, so they're processed from
+// the last to the first. The last operator modulates itself by the feedback amount.
+type fm4 struct {
+	freq, algorithm, feedback *In
+	ops                       [4]operatorInputs
+	operators                 [4]operator
+	out                       *Out
+}
+
+// fmAlgorithm describes how the operators of an FM voice are arranged: the operators modulating each operator, and
+// the operators that are heard.
+type fmAlgorithm struct {
+	modulators [4][]int
+	carriers   []int
+}
+
+var fm4Algorithms = []fmAlgorithm{
+	// 3 → 2 → 1 → 0
+	{modulators: [4][]int{{1}, {2}, {3}, nil}, carriers: []int{0}},
+	// (2 + 3) → 1 → 0
+	{modulators: [4][]int{{1}, {2, 3}, nil, nil}, carriers: []int{0}},
+	// ((2 → 1) + 3) → 0
+	{modulators: [4][]int{{1, 3}, {2}, nil, nil}, carriers: []int{0}},
+	// (1 + (3 → 2)) → 0
+	{modulators: [4][]int{{1, 2}, nil, {3}, nil}, carriers: []int{0}},
+	// (1 → 0) + (3 → 2)
+	{modulators: [4][]int{{1}, nil, {3}, nil}, carriers: []int{0, 2}},
+	// 3 → (0 + 1 + 2)
+	{modulators: [4][]int{{3}, {3}, {3}, nil}, carriers: []int{0, 1, 2}},
+	// 0 + 1 + (3 → 2)
+	{modulators: [4][]int{nil, nil, {3}, nil}, carriers: []int{0, 1, 2}},
+	// 0 + 1 + 2 + 3
+	{modulators: [4][]int{nil, nil, nil, nil}, carriers: []int{0, 1, 2, 3}},
+}
+
+func (fm *fm4) ProcessSample(i int) {
+	var (
+		freq      = fm.freq.Read(i)
+		feedback  = fm.feedback.Read(i)
+		algorithm = fm4Algorithms[fm.algorithm.ReadSlowInt(i, clampInt(0, float64(len(fm4Algorithms)-1)))]
+
+		outputs [4]float64
+	)
+	for op := len(fm.operators) - 1; op >= 0; op-- {
+		var pm float64
+		for _, m := range algorithm.modulators[op] {
+			pm += outputs[m]
+		}
+		var fb float64
+		if op == len(fm.operators)-1 {
+			fb = feedback
+		}
+		outputs[op] = fm.operators[op].tick(fm.ops[op].freq(i, freq), pm, fb, fm.ops[op].amp(i))
+	}
+
+	var sum float64
+	for _, c := range algorithm.carriers {
+		sum += outputs[c]
+	}
+	fm.out.Write(i, sum/float64(len(algorithm.carriers)))
+}
+
+// operatorInputs are the inputs controlling an FM operator. The frequency of the operator is the frequency of the
+// voice multiplied by its ratio, and its fine tuning; which is added to the ratio. Its output is scaled by its level and
+// envelope. Modulators' outputs are added to the phase of the operators they modulate, so their level is the index of
+// modulation in radians.
+type operatorInputs struct {
+	ratio, fine, level, envelope *In
+}
+
+func newOperatorInputs(io *IO, prefix string) operatorInputs {
+	return operatorInputs{
+		ratio:    io.NewIn(prefix+"ratio", dsp.Float64(1)),
+		fine:     io.NewIn(prefix+"fine", dsp.Float64(0)),
+		level:    io.NewIn(prefix+"level", dsp.Float64(1)),
+		envelope: io.NewIn(prefix+"envelope", dsp.Float64(1)),
+	}
+}
+
+func (in operatorInputs) freq(i int, freq float64) float64 {
+	return freq * (in.ratio.Read(i) + in.fine.Read(i))
+}
+
+func (in operatorInputs) amp(i int) float64 {
+	return in.level.Read(i) * in.envelope.Read(i)
+}
+
+// operator is a sine oscillator whose phase can be modulated; including by its own output.
+type operator struct {
+	phase, last, prev float64
+}
+
+// tick returns the next sample of the operator. Feedback is applied to the average of the last two samples, which
+// keeps high amounts of feedback from oscillating at the Nyquist frequency.
+func (o *operator) tick(freq, pm, feedback, amp float64) float64 {
+	out := amp * dsp.Sin(o.phase+pm+feedback*(o.last+o.prev)/2)
+	o.phase = stepPhase(freq, 0, o.phase, 1, 1)
+	o.prev, o.last = o.last, out
+	return out
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+)
+
+func TestFMOperator(t *testing.T) {
+	u, err := Builders()["fm-operator"](Config{SampleRate: sampleRate, FrameSize: frameSize})
+	require.NoError(t, err)
+
+	var (
+		freq = dsp.Frequency(100, sampleRate).Float64()
+		out  = u.Out["out"].Out()
+	)
+	u.In["freq"].Fill(dsp.Float64(freq))
+	u.In["ratio"].Fill(dsp.Float64(2))
+	u.In["fine"].Fill(dsp.Float64(0.5))
+	u.In["level"].Fill(dsp.Float64(0.5))
+	u.In["envelope"].Fill(dsp.Float64(0.5))
+	for i := 0; i < frameSize; i++ {
+		u.ProcessSample(i)
+		require.InDelta(t, 0.25*math.Sin(twoPi*2.5*freq*float64(i)), out.Read(i), 1e-4)
+	}
+}
+
+func TestFMOperator_Feedback(t *testing.T) {
+	u, err := Builders()["fm-operator"](Config{SampleRate: sampleRate, FrameSize: frameSize})
+	require.NoError(t, err)
+
+	var (
+		freq = dsp.Frequency(100, sampleRate).Float64()
+		out  = u.Out["out"].Out()
+	)
+	u.In["freq"].Fill(dsp.Float64(freq))
+	u.In["feedback"].Fill(dsp.Float64(1.5))
+
+	var differs bool
+	for i := 0; i < frameSize; i++ {
+		u.ProcessSample(i)
+		require.True(t, math.Abs(out.Read(i)) <= 1)
+		if math.Abs(out.Read(i)-math.Sin(twoPi*freq*float64(i))) > 0.1 {
+			differs = true
+		}
+	}
+	require.True(t, differs, "feedback changes the shape of the wave")
+}
+
+func TestFM4_Algorithms(t *testing.T) {
+	freq := dsp.Frequency(100, sampleRate).Float64()
+	sine := func(ratio float64, i int) float64 {
+		return math.Sin(twoPi * ratio * freq * float64(i))
+	}
+
+	tests := []struct {
+		algorithm float64
+		expected  func(i int) float64
+	}{
+		// Modulators are silenced, leaving only carriers.
+		{0, func(i int) float64 { return sine(1, i) }},
+		{4, func(i int) float64 { return (sine(1, i) + sine(3, i)) / 2 }},
+		{5, func(i int) float64 { return (sine(1, i) + sine(2, i) + sine(3, i)) / 3 }},
+		{7, func(i int) float64 { return (sine(1, i) + sine(2, i) + sine(3, i)) / 4 }},
+		{20, func(i int) float64 { return (sine(1, i) + sine(2, i) + sine(3, i)) / 4 }},
+	}
+
+	for _, test := range tests {
+		u, err := Builders()["fm4"](Config{SampleRate: sampleRate, FrameSize: frameSize})
+		require.NoError(t, err)
+
+		u.In["freq"].Fill(dsp.Float64(freq))
+		u.In["algorithm"].Fill(dsp.Float64(test.algorithm))
+		u.In["feedback"].Fill(dsp.Float64(2))
+		for op, ratio := range []float64{1, 2, 3, 4} {
+			u.In[fmt.Sprintf("%d/ratio", op)].Fill(dsp.Float64(ratio))
+		}
+		u.In["3/envelope"].Fill(dsp.Float64(0))
+		if test.algorithm < 5 {
+			u.In["1/level"].Fill(dsp.Float64(0))
+		}
+
+		out := u.Out["out"].Out()
+		for i := 0; i < frameSize; i++ {
+			u.ProcessSample(i)
+			require.InDelta(t, test.expected(i), out.Read(i), 1e-4, "algorithm %v, sample %d", test.algorithm, i)
+		}
+	}
+}
+
+func TestFM4_Modulation(t *testing.T) {
+	u, err := Builders()["fm4"](Config{SampleRate: sampleRate, FrameSize: frameSize})
+	require.NoError(t, err)
+
+	freq := dsp.Frequency(100, sampleRate).Float64()
+	u.In["freq"].Fill(dsp.Float64(freq))
+	u.In["1/level"].Fill(dsp.Float64(2))
+	u.In["2/level"].Fill(dsp.Float64(0))
+	u.In["3/level"].Fill(dsp.Float64(0))
+
+	out := u.Out["out"].Out()
+	for i := 0; i < frameSize; i++ {
+		u.ProcessSample(i)
+		p := twoPi * freq * float64(i)
+		require.InDelta(t, math.Sin(p+2*math.Sin(p)), out.Read(i), 1e-3)
+	}
+}
@@ -13,6 +13,13 @@ const (
 )
 
 func newGen(io *IO, c Config) (*Unit, error) {
+	var config struct {
+		Antialias bool
+	}
+	if err := c.Decode(&config); err != nil {
+		return nil, err
+	}
+
 	g := &gen{
 		freq:      io.NewIn("freq", dsp.Frequency(440, c.SampleRate)),
 		amp:       io.NewIn("amp", dsp.Float64(1)),
@@ -22,6 +29,8 @@ func newGen(io *IO, c Config) (*Unit, error) {
 		sync:      io.NewIn("sync", dsp.Float64(-1)),
 		offset:    io.NewIn("offset", dsp.Float64(0)),
 		frameSize: c.FrameSize,
+		rand:      c.Rand,
+		antialias: config.Antialias,
 	}
 
 	io.ExposeOutputProcessor(g.newSine("sine", 1))
@@ -40,6 +49,11 @@ func newGen(io *IO, c Config) (*Unit, error) {
 type gen struct {
 	freq, amp, fm, pw, sync, pm, offset *In
 	frameSize                           int
+	rand                                *rand.Rand
+
+	// antialias band-limits the discontinuities of the saw and pulse waveforms, and the corners of the triangle,
+	// wherever the phase (including phase modulation) places them.
+	antialias bool
 }
 
 func (g *gen) newFrame() []float64 {
@@ -49,7 +63,7 @@ func (g *gen) newFrame() []float64 {
 func (g *gen) newSine(name string, mult float64) *genSine {
 	return &genSine{
 		gen:   g,
-		phase: rand.Float64() * twoPi,
+		phase: g.rand.Float64() * twoPi,
 		mult:  mult,
 		out:   NewOut(name, g.newFrame()),
 	}
@@ -58,7 +72,7 @@ func (g *gen) newSine(name string, mult float64) *genSine {
 func (g *gen) newSaw(name string, mult float64) *genSaw {
 	return &genSaw{
 		gen:   g,
-		phase: rand.Float64() * twoPi,
+		phase: g.rand.Float64() * twoPi,
 		mult:  mult,
 		out:   NewOut(name, g.newFrame()),
 	}
@@ -67,7 +81,7 @@ func (g *gen) newSaw(name string, mult float64) *genSaw {
 func (g *gen) newPulse(name string, mult float64) *genPulse {
 	return &genPulse{
 		gen:   g,
-		phase: rand.Float64() * twoPi,
+		phase: g.rand.Float64() * twoPi,
 		mult:  mult,
 		out:   NewOut(name, g.newFrame()),
 	}
@@ -76,25 +90,33 @@ func (g *gen) newPulse(name string, mult float64) *genPulse {
 func (g *gen) newTriangle() *genTriangle {
 	return &genTriangle{
 		gen:   g,
-		phase: rand.Float64() * twoPi,
+		phase: g.rand.Float64() * twoPi,
 		out:   NewOut("triangle", g.newFrame()),
 	}
 }
 
 func (g *gen) newNoise() *genNoise {
 	return &genNoise{
-		gen: g,
-		out: NewOut("noise", g.newFrame()),
+		gen:  g,
+		out:  NewOut("noise", g.newFrame()),
+		rand: g.newRand(),
 	}
 }
 
 func (g *gen) newCluster() *genCluster {
 	return &genCluster{
-		gen: g,
-		out: NewOut("cluster", g.newFrame()),
+		gen:  g,
+		out:  NewOut("cluster", g.newFrame()),
+		rand: g.newRand(),
 	}
 }
 
+// newRand returns a random source, derived from the Unit's, for an output. The outputs of a Unit may be processed
+// concurrently; so they can't share one.
+func (g *gen) newRand() *rand.Rand {
+	return rand.New(rand.NewSource(g.rand.Int63()))
+}
+
 type genSine struct {
 	*gen
 	phase, mult, lastSync float64
@@ -161,9 +183,15 @@ func (o *genSaw) ProcessSample(i int) {
 		o.phase = 0
 	}
 
-	p := (o.phase + pm) / twoPi
-	next = (2*p - 1)
-	next -= blep(p, freq, fm)
+	if o.antialias {
+		p := wrapPhase((o.phase + pm) / twoPi)
+		next = 2*p - 1
+		next -= polyBLEP(p, math.Abs(freq+fm))
+	} else {
+		p := (o.phase + pm) / twoPi
+		next = (2*p - 1)
+		next -= blep(p, freq, fm)
+	}
 	o.phase = stepPhase(freq, fm, o.phase, o.frameSize, o.frameSize)
 	o.out.Write(i, (amp*next)+offset)
 	o.lastSync = sync
@@ -201,14 +229,18 @@ func (o *genPulse) ProcessSample(i int) {
 		o.phase = 0
 	}
 
-	if o.phase+pm < math.Pi*pw {
-		next = 1
+	if o.antialias {
+		next = pulse(wrapPhase((o.phase+pm)/twoPi), dsp.Clamp(pw/2, 0, 1), math.Abs(freq+fm))
 	} else {
-		next = -1
+		if o.phase+pm < math.Pi*pw {
+			next = 1
+		} else {
+			next = -1
+		}
+		p := (o.phase + pm) / twoPi
+		next += blep(p, freq, fm)
+		next -= blep(math.Mod(p+0.5, 1), freq, fm)
 	}
-	p := (o.phase + pm) / twoPi
-	next += blep(p, freq, fm)
-	next -= blep(math.Mod(p+0.5, 1), freq, fm)
 
 	o.phase = stepPhase(freq, fm, o.phase, o.frameSize, o.frameSize)
 	o.out.Write(i, (amp*next)+offset)
@@ -245,6 +277,14 @@ func (o *genTriangle) ProcessSample(i int) {
 		o.phase = 0
 	}
 
+	if o.antialias {
+		next = triangle(wrapPhase((o.phase+pm)/twoPi), math.Abs(freq+fm))
+		o.phase = stepPhase(freq, fm, o.phase, o.frameSize, o.frameSize)
+		o.out.Write(i, (amp*next)+offset)
+		o.lastSync = sync
+		return
+	}
+
 	if o.phase+pm < math.Pi {
 		next = 1
 	} else {
@@ -263,7 +303,8 @@ func (o *genTriangle) ProcessSample(i int) {
 
 type genNoise struct {
 	*gen
-	out *Out
+	out  *Out
+	rand *rand.Rand
 }
 
 func (o *genNoise) IsProcessable() bool { return o.out.ExternalNeighborCount() > 0 }
@@ -280,12 +321,13 @@ func (o *genNoise) ProcessSample(i int) {
 		offset = o.offset.Read(i)
 		amp    = o.amp.Read(i)
 	)
-	o.out.Write(i, dsp.RandRange(-1, 1)*amp+offset)
+	o.out.Write(i, dsp.RandRange(o.rand, -1, 1)*amp+offset)
 }
 
 type genCluster struct {
 	*gen
-	out *Out
+	out  *Out
+	rand *rand.Rand
 }
 
 func (o *genCluster) IsProcessable() bool { return o.out.ExternalNeighborCount() > 0 }
@@ -302,7 +344,7 @@ func (o *genCluster) ProcessSample(i int) {
 		offset = o.offset.Read(i)
 		amp    = o.amp.Read(i)
 	)
-	d := (-math.Log(rand.Float64()) + math.Log(rand.Float64())) * 0.1
+	d := (-math.Log(o.rand.Float64()) + math.Log(o.rand.Float64())) * 0.1
 	if d > 0.5 || d < -0.5 {
 		o.out.Write(i, d*amp+offset)
 	} else {
@@ -329,3 +371,62 @@ func blep(p float64, freq, fm float64) float64 {
 	}
 	return 0
 }
+
+// pulse returns a band-limited pulse wave that is high for the first part of each cycle, given by width; p is the phase
+// within the cycle and dt the phase increment per sample.
+func pulse(p, width, dt float64) float64 {
+	next := -1.0
+	if p < width {
+		next = 1
+	}
+	next += polyBLEP(p, dt)
+	next -= polyBLEP(wrapPhase(p-width), dt)
+	return next
+}
+
+// triangle returns a band-limited triangle wave, rising from -1 at the start of each cycle to 1 halfway through it; p
+// is the phase within the cycle and dt the phase increment per sample.
+func triangle(p, dt float64) float64 {
+	next := 1 - 4*math.Abs(p-0.5)
+	next += 4 * dt * polyBLAMP(p, dt)
+	next -= 4 * dt * polyBLAMP(wrapPhase(p-0.5), dt)
+	return next
+}
+
+// polyBLEP returns the correction for a discontinuity at the start of the cycle; p is the phase within the cycle and dt
+// the phase increment per sample. Subtracting it from a waveform smooths a falling step of 2 over the samples on either
+// side of it; adding it smooths a rising one.
+func polyBLEP(p, dt float64) float64 {
+	if dt == 0 {
+		return 0
+	}
+	if p < dt {
+		p /= dt
+		return p + p - p*p - 1
+	} else if p > 1-dt {
+		p = (p - 1) / dt
+		return p + p + p*p + 1
+	}
+	return 0
+}
+
+// polyBLAMP returns the residual of a band-limited ramp: the integral of polyBLEP. Scaled by the change of slope (per
+// sample) it rounds off a corner at the start of the cycle.
+func polyBLAMP(p, dt float64) float64 {
+	if dt == 0 {
+		return 0
+	}
+	if p < dt {
+		p = p/dt - 1
+		return -p * p * p / 3
+	} else if p > 1-dt {
+		p = (p-1)/dt + 1
+		return p * p * p / 3
+	}
+	return 0
+}
+
+// wrapPhase wraps a phase into the range [0, 1).
+func wrapPhase(p float64) float64 {
+	return p - math.Floor(p)
+}
@@ -1,7 +1,9 @@
 package unit
 
 import (
+	"math"
 	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/brettbuddin/shaden/dsp"
@@ -23,12 +25,11 @@ var _ = []genOutput{
 }
 
 func TestGen_Sine(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -45,12 +46,11 @@ func TestGen_Sine(t *testing.T) {
 }
 
 func TestGen_Saw(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -67,12 +67,11 @@ func TestGen_Saw(t *testing.T) {
 }
 
 func TestGen_Pulse(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -93,12 +92,11 @@ func TestGen_Pulse(t *testing.T) {
 }
 
 func TestGen_Triangle(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -115,3 +113,144 @@ func TestGen_Triangle(t *testing.T) {
 	require.NotEqual(t, 0.0, out.Out().Read(0))
 	require.NotEqual(t, 0.0, out.Out().Read(170))
 }
+
+func TestGen_Antialias(t *testing.T) {
+	const f0 = 2489.0 // Bright enough that the harmonics of a naive waveform fold over many times
+
+	naive := map[string]func(p float64) float64{
+		"saw":       func(p float64) float64 { return 2*p - 1 },
+		"sub-saw":   func(p float64) float64 { return 2*p - 1 },
+		"pulse":     func(p float64) float64 { return naivePulse(p, 0.3) },
+		"sub-pulse": func(p float64) float64 { return naivePulse(p, 0.3) },
+		"triangle":  func(p float64) float64 { return 1 - 4*math.Abs(p-0.5) },
+	}
+	for name, waveform := range naive {
+		t.Run(name, func(t *testing.T) {
+			freq := f0
+			if strings.HasPrefix(name, "sub-") {
+				freq /= 2
+			}
+			u := antialiasedGen(t)
+			u.In["freq"].Fill(dsp.Frequency(f0, sampleRate))
+			u.In["pulse-width"].Fill(dsp.Float64(0.6))
+
+			var (
+				out       = u.Out[name].(OutputProcessor)
+				limited   = make([]float64, 0, aliasingSize)
+				naive     = make([]float64, aliasingSize)
+				increment = freq / sampleRate
+			)
+			for len(limited) < aliasingSize {
+				out.ProcessFrame(frameSize)
+				for i := 0; i < frameSize; i++ {
+					limited = append(limited, out.Out().Read(i))
+				}
+			}
+			for i := range naive {
+				naive[i] = waveform(wrapPhase(float64(i) * increment))
+			}
+
+			aliased := aliasing(limited, freq)
+			require.True(t, aliased < aliasing(naive, freq)-8, "%.1fdB of aliasing", aliased)
+		})
+	}
+}
+
+func TestGen_AntialiasPulseWidth(t *testing.T) {
+	render := func(u *Unit) []float64 {
+		u.In["freq"].Fill(dsp.Frequency(2489, sampleRate))
+		u.In["pulse-width"].Fill(dsp.Float64(0.6))
+		out := u.Out["pulse"].(OutputProcessor)
+		signal := make([]float64, 0, aliasingSize)
+		for len(signal) < aliasingSize {
+			out.ProcessFrame(frameSize)
+			for i := 0; i < frameSize; i++ {
+				signal = append(signal, out.Out().Read(i))
+			}
+		}
+		return signal
+	}
+
+	u, err := Builders()["gen"](Config{
+		SampleRate: sampleRate,
+		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
+	})
+	require.NoError(t, err)
+
+	// Without antialiasing the falling edge of the pulse is only smoothed at a width of 1.
+	require.True(t, aliasing(render(antialiasedGen(t)), 2489) < aliasing(render(u), 2489)-10)
+}
+
+func TestGen_AntialiasPhaseMod(t *testing.T) {
+	u := antialiasedGen(t)
+	u.In["freq"].Fill(dsp.Frequency(440, sampleRate))
+	u.In["phase-mod"].Fill(dsp.Float64(-7))
+
+	for _, name := range []string{"saw", "pulse", "triangle"} {
+		out := u.Out[name].(OutputProcessor)
+		for n := 0; n < 4; n++ {
+			out.ProcessFrame(frameSize)
+			for i := 0; i < frameSize; i++ {
+				v := out.Out().Read(i)
+				require.True(t, v >= -1.5 && v <= 1.5, "%s out of range: %f", name, v)
+			}
+		}
+	}
+}
+
+const aliasingSize = 1 << 14
+
+func antialiasedGen(t *testing.T) *Unit {
+	u, err := Builders()["gen"](Config{
+		Values:     map[string]interface{}{"antialias": true},
+		SampleRate: sampleRate,
+		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
+	})
+	require.NoError(t, err)
+	return u
+}
+
+func naivePulse(p, width float64) float64 {
+	if p < width {
+		return 1
+	}
+	return -1
+}
+
+// aliasing returns the energy of a signal outside of the harmonics of its fundamental, relative to its total energy, in
+// decibels.
+func aliasing(signal []float64, freq float64) float64 {
+	const window = 8 // Bins either side of a harmonic that are part of it; covering the main lobe of the window.
+
+	n := len(signal)
+	bins := make([]complex128, n)
+	for i, v := range signal {
+		// Blackman-Harris window
+		x := 2 * math.Pi * float64(i) / float64(n-1)
+		w := 0.35875 - 0.48829*math.Cos(x) + 0.14128*math.Cos(2*x) - 0.01168*math.Cos(3*x)
+		bins[i] = complex(v*w, 0)
+	}
+	dsp.FFT(bins)
+
+	harmonic := make([]bool, n/2)
+	for h := 0.0; h < sampleRate/2; h += freq {
+		center := int(math.Round(h / sampleRate * float64(n)))
+		for b := center - window; b <= center+window; b++ {
+			if b >= 0 && b < len(harmonic) {
+				harmonic[b] = true
+			}
+		}
+	}
+
+	var total, aliased float64
+	for b := 0; b < n/2; b++ {
+		power := real(bins[b])*real(bins[b]) + imag(bins[b])*imag(bins[b])
+		total += power
+		if !harmonic[b] {
+			aliased += power
+		}
+	}
+	return 10 * math.Log10(aliased/total)
+}
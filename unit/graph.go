@@ -6,6 +6,11 @@ import (
 
 // Patch connects a one Unit's Out to another's In. It also creates an edge on a graph to track the connection.
 func Patch(g *graph.Graph, out Output, in *In) error {
+	if in.Delayed() {
+		if err := Unpatch(g, in); err != nil {
+			return err
+		}
+	}
 	if err := g.NewConnection(out.Out().node, in.node); err != nil {
 		return err
 	}
@@ -16,7 +21,11 @@ func Patch(g *graph.Graph, out Output, in *In) error {
 // Unpatch disconnects all inbound neighbors (Outs) from an In. All graph edges are removed as well to track the
 // disconnection. Once all, if any, Outs are disconnected the In is reset to its default value constant.
 func Unpatch(g *graph.Graph, in *In) error {
-	if in.HasSource() {
+	if in.Delayed() {
+		if err := in.feedback.remove(g); err != nil {
+			return err
+		}
+	} else if in.HasSource() {
 		inputs := in.node.InNeighbors()
 		for _, n := range inputs {
 			if err := g.RemoveConnection(n, in.node); err != nil {
@@ -27,3 +36,23 @@ func Unpatch(g *graph.Graph, in *In) error {
 	in.Reset()
 	return nil
 }
+
+// FeedbackLoop returns the Units of the feedback loop a Unit is part of, or nil if it isn't part of one.
+func FeedbackLoop(g *graph.Graph, u *Unit) []*Unit {
+	nodes := g.Component(u.node)
+	if len(nodes) < 2 {
+		return nil
+	}
+	return UnitsOf(nodes)
+}
+
+// UnitsOf returns the Units among a list of Nodes.
+func UnitsOf(nodes []*graph.Node) []*Unit {
+	var units []*Unit
+	for _, n := range nodes {
+		if u, ok := n.Value.(*Unit); ok {
+			units = append(units, u)
+		}
+	}
+	return units
+}
@@ -23,10 +23,11 @@ const controlPeriod = 64
 type In struct {
 	Name               string
 	Mode               InMode
-	normal             dsp.Valuer
+	normal, constant   dsp.Valuer
 	frame, normalFrame []float64
 	unit               *Unit
 	source             *Out
+	feedback           *feedback
 	node               *graph.Node
 
 	controlLastF float64
@@ -75,11 +76,25 @@ func (in *In) ReadSlowInt(i int, f func(float64) int) int {
 
 // Fill fills the internal frame with a specific constant value
 func (in *In) Fill(v dsp.Valuer) {
+	in.constant = v
 	for i := range in.frame {
 		in.frame[i] = v.Float64()
 	}
 }
 
+// Ramp fills the internal frame with values that move linearly from one value to a constant; reaching it at the last
+// sample. The constant is held from then on.
+func (in *In) Ramp(from float64, to dsp.Valuer) {
+	in.constant = to
+	var (
+		end  = to.Float64()
+		size = float64(len(in.frame))
+	)
+	for i := range in.frame {
+		in.frame[i] = dsp.Lerp(from, end, float64(i+1)/size)
+	}
+}
+
 // Write writes a sample to the internal buffer
 func (in *In) Write(i int, v float64) {
 	in.frame[i] = v
@@ -98,6 +113,30 @@ func (in *In) HasSource() bool {
 	return in.source != nil
 }
 
+// Delayed returns whether the source of this input is patched into it through a one block delay
+func (in *In) Delayed() bool {
+	return in.feedback != nil
+}
+
+// Source returns the output patched into this input, or nil if it has none
+func (in *In) Source() *Out {
+	return in.source
+}
+
+// Constant returns the constant value of an input that has no inbound connection, or nil if it has one. The value is
+// either the normal value of the input or a constant that has been patched into it.
+func (in *In) Constant() dsp.Valuer {
+	if in.HasSource() {
+		return nil
+	}
+	return in.constant
+}
+
+// Normal returns the value the input takes when nothing is patched into it
+func (in *In) Normal() dsp.Valuer {
+	return in.normal
+}
+
 // Reset disconnects an input from an output (if a connection has been established) and fills the frame with the normal
 // constant value
 func (in *In) Reset() {
@@ -106,6 +145,11 @@ func (in *In) Reset() {
 	in.Fill(in.normal)
 }
 
+// Unit returns the parent Unit
+func (in *In) Unit() *Unit {
+	return in.unit
+}
+
 // ExternalNeighborCount returns the count of neighboring nodes outside of the parent Unit
 func (in *In) ExternalNeighborCount() int {
 	return in.node.InNeighborCount()
@@ -44,6 +44,13 @@ func TestIn_Fill(t *testing.T) {
 	require.Equal(t, 101.0, in.Read(10))
 }
 
+func TestIn_Ramp(t *testing.T) {
+	in := NewIn("in", dsp.Float64(0), 4)
+	in.Ramp(1, dsp.Float64(3))
+	require.Equal(t, []float64{1.5, 2, 2.5, 3}, in.frame)
+	require.Equal(t, dsp.Float64(3), in.Constant())
+}
+
 func TestIn_CoupleOutput(t *testing.T) {
 	g := graph.New()
 
@@ -12,6 +12,7 @@ var idCount uint32
 // IO is the registry of inputs, outputs and properties for a Module
 type IO struct {
 	ID, Type  string
+	Config    map[string]interface{}
 	Prop      map[string]*Prop
 	In        map[string]*In
 	Out       map[string]Output
@@ -20,16 +21,18 @@ type IO struct {
 
 // NewIO returns a new IO
 func NewIO(typ string, frameSize int) *IO {
-	io := &IO{
-		ID:        fmt.Sprintf("%s-%d", typ, idCount),
+	return newIO(typ, atomic.AddUint32(&idCount, 1)-1, frameSize)
+}
+
+func newIO(typ string, n uint32, frameSize int) *IO {
+	return &IO{
+		ID:        fmt.Sprintf("%s-%d", typ, n),
 		Type:      typ,
 		Prop:      map[string]*Prop{},
 		In:        map[string]*In{},
 		Out:       map[string]Output{},
 		frameSize: frameSize,
 	}
-	atomic.AddUint32(&idCount, 1)
-	return io
 }
 
 // NewProp registers a new property
@@ -15,6 +15,7 @@ func newLowGen(io *IO, c Config) (*Unit, error) {
 		offset:    io.NewIn("offset", dsp.Float64(0)),
 		sync:      io.NewIn("sync", dsp.Float64(-1)),
 		frameSize: c.FrameSize,
+		rand:      c.Rand,
 	}
 
 	io.ExposeOutputProcessor(g.newSine())
@@ -30,6 +31,7 @@ func newLowGen(io *IO, c Config) (*Unit, error) {
 type lowGen struct {
 	freq, amp, pw, offset, sync *In
 	frameSize                   int
+	rand                        *rand.Rand
 }
 
 func (g *lowGen) newFrame() []float64 {
@@ -39,7 +41,7 @@ func (g *lowGen) newFrame() []float64 {
 func (g *lowGen) newSine() *lowGenSine {
 	return &lowGenSine{
 		lowGen: g,
-		phase:  rand.Float64() * twoPi,
+		phase:  g.rand.Float64() * twoPi,
 		out:    NewOut("sine", g.newFrame()),
 	}
 }
@@ -47,7 +49,7 @@ func (g *lowGen) newSine() *lowGenSine {
 func (g *lowGen) newSaw() *lowGenSaw {
 	return &lowGenSaw{
 		lowGen: g,
-		phase:  rand.Float64() * twoPi,
+		phase:  g.rand.Float64() * twoPi,
 		out:    NewOut("saw", g.newFrame()),
 	}
 }
@@ -55,7 +57,7 @@ func (g *lowGen) newSaw() *lowGenSaw {
 func (g *lowGen) newPulse() *lowGenPulse {
 	return &lowGenPulse{
 		lowGen: g,
-		phase:  rand.Float64() * twoPi,
+		phase:  g.rand.Float64() * twoPi,
 		out:    NewOut("pulse", g.newFrame()),
 	}
 }
@@ -63,7 +65,7 @@ func (g *lowGen) newPulse() *lowGenPulse {
 func (g *lowGen) newTriangle() *lowGenTriangle {
 	return &lowGenTriangle{
 		lowGen: g,
-		phase:  rand.Float64() * twoPi,
+		phase:  g.rand.Float64() * twoPi,
 		out:    NewOut("triangle", g.newFrame()),
 	}
 }
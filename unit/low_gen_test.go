@@ -16,12 +16,11 @@ var _ = []genOutput{
 }
 
 func TestLowGen_Sine(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["low-gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -38,12 +37,11 @@ func TestLowGen_Sine(t *testing.T) {
 }
 
 func TestLowGen_Saw(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["low-gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -60,12 +58,11 @@ func TestLowGen_Saw(t *testing.T) {
 }
 
 func TestLowGen_Pulse(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["low-gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -82,12 +79,11 @@ func TestLowGen_Pulse(t *testing.T) {
 }
 
 func TestLowGen_Triangle(t *testing.T) {
-	rand.Seed(1)
-
 	builder := Builders()["low-gen"]
 	u, err := builder(Config{
 		SampleRate: sampleRate,
 		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
 	})
 	require.NoError(t, err)
 
@@ -25,6 +25,9 @@ type Out struct {
 	unit  *Unit
 	node  *graph.Node
 	frame []float64
+
+	// delayed are the inputs this output is patched into through a one block delay.
+	delayed []*In
 }
 
 // NewOut returns a new output
@@ -40,6 +43,11 @@ func (out *Out) Out() *Out {
 	return out
 }
 
+// Unit returns the parent Unit
+func (out *Out) Unit() *Unit {
+	return out.unit
+}
+
 // Rate returns the rate of the parent Unit
 func (out *Out) Rate() Rate {
 	return out.unit.rate
@@ -47,7 +55,22 @@ func (out *Out) Rate() Rate {
 
 // DestinationCount returns the number of outbound connections to this output
 func (out *Out) DestinationCount() int {
-	return out.node.OutNeighborCount()
+	return out.node.OutNeighborCount() + len(out.delayed)
+}
+
+// Destinations returns the inputs this output is patched into
+func (out *Out) Destinations() []*In {
+	if out.node == nil {
+		return nil
+	}
+	nodes := out.node.OutNeighbors()
+	ins := make([]*In, 0, len(nodes)+len(out.delayed))
+	for _, n := range nodes {
+		if in, ok := n.Value.(*In); ok {
+			ins = append(ins, in)
+		}
+	}
+	return append(ins, out.delayed...)
 }
 
 // Write writes a sample to the output frame if there are downstream consumers of the output
@@ -62,7 +85,7 @@ func (out *Out) Read(i int) float64 {
 
 // ExternalNeighborCount returns the count of neighboring nodes outside of the parent Unit
 func (out *Out) ExternalNeighborCount() int {
-	return out.node.OutNeighborCount()
+	return out.node.OutNeighborCount() + len(out.delayed)
 }
 
 func (out *Out) String() string {
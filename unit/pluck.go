@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/brettbuddin/shaden/dsp"
+)
+
+// minPluckFreq is the lowest frequency, in hertz, that a pluck can be tuned to.
+const minPluckFreq = 20
+
+func newPluck(io *IO, c Config) (*Unit, error) {
+	return NewUnit(io, &pluck{
+		trigger:     io.NewIn("trigger", dsp.Float64(-1)),
+		freq:        io.NewIn("freq", dsp.Frequency(220, c.SampleRate)),
+		decay:       io.NewIn("decay", dsp.Duration(2000, c.SampleRate)),
+		brightness:  io.NewIn("brightness", dsp.Float64(0.5)),
+		exciter:     io.NewIn("exciter", dsp.Float64(0)),
+		out:         io.NewOut("out"),
+		dl:          dsp.NewDelayLine(c.SampleRate/minPluckFreq + 2),
+		rand:        c.Rand,
+		lastTrigger: -1,
+	}), nil
+}
+
+// pluck is a Karplus-Strong string. Its loop is made of a delay line, tuned to fractions of a sample by an allpass
+// filter, and a damping filter whose delay is accounted for in the tuning. It's excited by a burst of noise when
+// triggered, and continuously by its exciter input.
+type pluck struct {
+	trigger, freq, decay, brightness, exciter *In
+	out                                       *Out
+
+	dl                       *dsp.DelayLine
+	tuning                   dsp.Thiran
+	rand                     *rand.Rand
+	burst                    int
+	lastTrigger, noise, prev float64
+}
+
+func (p *pluck) ProcessSample(i int) {
+	var (
+		trigger    = p.trigger.Read(i)
+		freq       = math.Abs(p.freq.Read(i))
+		decay      = math.Max(p.decay.Read(i), 1)
+		brightness = dsp.Clamp(p.brightness.Read(i), 0, 1)
+		excitation = p.exciter.Read(i)
+		maxPeriod  = float64(p.dl.Size() - 2)
+	)
+
+	period := maxPeriod
+	if freq > 0 {
+		period = dsp.Clamp(1/freq, 2, maxPeriod)
+	}
+
+	// The damping filter averages the last two samples; the darker the string, the more even the average. It delays
+	// the loop by the weight given to the older sample.
+	damping := 0.5 * (1 - brightness)
+
+	// The delay line covers the whole samples of the period; leaving the allpass filter a delay between 0.5 and 1.5.
+	var (
+		delay      = period - damping
+		whole      = math.Floor(delay - 0.5)
+		fractional = delay - whole
+	)
+
+	if isTrig(p.lastTrigger, trigger) {
+		p.burst = int(period)
+	}
+	if p.burst > 0 {
+		// Darker strings are excited with darker noise.
+		p.noise += (dsp.RandRange(p.rand, -1, 1) - p.noise) * (0.1 + 0.9*brightness)
+		excitation += p.noise
+		p.burst--
+	}
+
+	var (
+		tuned = p.tuning.Tick(p.dl.ReadAbsolute(whole), fractional)
+		// The gain of a trip around the loop that decays the string by 60dB over the decay time.
+		gain = math.Pow(10, -3*period/decay)
+		next = gain*((1-damping)*tuned+damping*p.prev) + excitation
+	)
+	p.dl.Write(next)
+	p.out.Write(i, next)
+	p.prev = tuned
+	p.lastTrigger = trigger
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+)
+
+func newTestPluck(t *testing.T) *Unit {
+	u, err := Builders()["pluck"](Config{
+		SampleRate: sampleRate,
+		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
+	})
+	require.NoError(t, err)
+	return u
+}
+
+// renderPluck triggers a pluck and returns a number of samples of its output.
+func renderPluck(u *Unit, n int) []float64 {
+	var (
+		trigger = u.In["trigger"]
+		out     = u.Out["out"].Out()
+		samples []float64
+	)
+	for len(samples) < n {
+		for i := 0; i < frameSize; i++ {
+			if len(samples) == 0 && i == 0 {
+				trigger.Write(i, 1)
+			} else {
+				trigger.Write(i, -1)
+			}
+			u.ProcessSample(i)
+			samples = append(samples, out.Read(i))
+		}
+	}
+	return samples[:n]
+}
+
+func TestPluck_Tuning(t *testing.T) {
+	for _, brightness := range []float64{0, 0.5, 1} {
+		u := newTestPluck(t)
+		u.In["freq"].Fill(dsp.Frequency(330, sampleRate))
+		u.In["brightness"].Fill(dsp.Float64(brightness))
+		samples := renderPluck(u, 4096)
+
+		// Find the fundamental from the peak of the spectrum around it.
+		magnitude := func(freq float64) float64 {
+			var re, im float64
+			for i, v := range samples {
+				w := v * (1 - math.Cos(twoPi*float64(i)/float64(len(samples))))
+				re += w * math.Cos(twoPi*freq*float64(i)/sampleRate)
+				im += w * math.Sin(twoPi*freq*float64(i)/sampleRate)
+			}
+			return re*re + im*im
+		}
+		var fundamental, peak float64
+		for freq := 320.0; freq < 340; freq += 0.05 {
+			if m := magnitude(freq); m > peak {
+				fundamental, peak = freq, m
+			}
+		}
+		require.InDelta(t, 330, fundamental, 0.2, "brightness %v", brightness)
+	}
+}
+
+func TestPluck_Decay(t *testing.T) {
+	u := newTestPluck(t)
+	u.In["freq"].Fill(dsp.Frequency(441, sampleRate))
+	u.In["brightness"].Fill(dsp.Float64(1))
+	u.In["decay"].Fill(dsp.Duration(100, sampleRate))
+	samples := renderPluck(u, 5000)
+
+	rms := func(from int) float64 {
+		var sum float64
+		for _, v := range samples[from : from+100] {
+			sum += v * v
+		}
+		return math.Sqrt(sum / 100)
+	}
+	// The string is excited for its first period; 100ms later it has decayed by 60dB.
+	require.InDelta(t, -60, 20*math.Log10(rms(4510)/rms(100)), 1)
+}
+
+func TestPluck_Exciter(t *testing.T) {
+	u := newTestPluck(t)
+	u.In["freq"].Fill(dsp.Frequency(441, sampleRate))
+
+	var (
+		exciter = u.In["exciter"]
+		out     = u.Out["out"].Out()
+	)
+	for i := 0; i < frameSize; i++ {
+		u.ProcessSample(i)
+		require.Zero(t, out.Read(i), "silent until excited")
+	}
+
+	exciter.Write(0, 1)
+	for i := 0; i < frameSize; i++ {
+		if i > 0 {
+			exciter.Write(i, 0)
+		}
+		u.ProcessSample(i)
+	}
+	require.Equal(t, 1.0, out.Read(0))
+	require.Zero(t, out.Read(50))
+	require.NotZero(t, out.Read(100), "the impulse comes around the loop a period later")
+}
@@ -31,6 +31,12 @@ func (p *Prop) SetValue(v interface{}) error {
 	return p.setter(p, v)
 }
 
+// Restore sets the Prop's value directly; bypassing its PropSetterFunc. It's used to restore a value previously
+// returned by Value.
+func (p *Prop) Restore(v interface{}) {
+	p.value = v
+}
+
 // InvalidPropValueError is an error that indicates a Prop cannot handle a value that's been given to it
 type InvalidPropValueError struct {
 	Prop  *Prop
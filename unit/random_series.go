@@ -7,7 +7,7 @@ import (
 	"github.com/brettbuddin/shaden/dsp"
 )
 
-func newRandomSeries(io *IO, _ Config) (*Unit, error) {
+func newRandomSeries(io *IO, c Config) (*Unit, error) {
 	gates := make([]float64, 16)
 	for i := range gates {
 		gates[i] = -1
@@ -24,6 +24,7 @@ func newRandomSeries(io *IO, _ Config) (*Unit, error) {
 		gate:      io.NewOut("gate"),
 		value:     io.NewOut("value"),
 		lastClock: -1,
+		rand:      c.Rand,
 	}), nil
 }
 
@@ -34,6 +35,7 @@ type randomSeries struct {
 
 	idx       int
 	lastClock float64
+	rand      *rand.Rand
 }
 
 func (s *randomSeries) ProcessSample(i int) {
@@ -49,7 +51,7 @@ func (s *randomSeries) ProcessSample(i int) {
 	if isTrig(s.lastClock, clock) {
 		var (
 			lastGate, lastValue = s.gates[lengthInt-1], s.values[lengthInt-1]
-			data                = rand.Float64()
+			data                = s.rand.Float64()
 		)
 		for i := 0; i < lengthInt; i++ {
 			s.gates[i], lastGate = lastGate, s.gates[i]
@@ -61,7 +63,7 @@ func (s *randomSeries) ProcessSample(i int) {
 			} else {
 				s.gates[0] = 1
 			}
-			s.values[0] = dsp.Lerp(min, max, rand.Float64())
+			s.values[0] = dsp.Lerp(min, max, s.rand.Float64())
 		}
 	}
 
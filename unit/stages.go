@@ -62,6 +62,7 @@ func newStages(io *IO, c Config) (*Unit, error) {
 		lastStage:   -1,
 		lastClock:   -1,
 		lastReset:   -1,
+		rand:        c.Rand,
 	}), nil
 }
 
@@ -92,6 +93,7 @@ type stages struct {
 	pong, firstPulse        bool
 	stage, pulse, lastStage int
 	lastClock, lastReset    float64
+	rand                    *rand.Rand
 }
 
 func (s *stages) ProcessSample(i int) {
@@ -175,7 +177,7 @@ func (s *stages) advanceStage(totalStages, mode int) {
 
 		s.stage += inc
 	case patternModeRandom:
-		s.stage = rand.Intn(totalStages)
+		s.stage = s.rand.Intn(totalStages)
 		s.pong = false
 	}
 }
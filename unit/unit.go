@@ -36,8 +36,9 @@ const (
 type Unit struct {
 	*IO
 	SampleProcessor
-	rate Rate
-	node *graph.Node
+	rate   Rate
+	node   *graph.Node
+	closed bool
 }
 
 // NewUnit creates a new Unit that defaults to audio rate.
@@ -65,8 +66,12 @@ func (u *Unit) ProcessFrame(n int) {
 	}
 }
 
-// Close closes the Processor if it is an io.Closer. It also closes any Outs that it has.
+// Close closes the Processor if it is an io.Closer. It also closes any Outs that it has. Closing a Unit more than once
+// has no effect.
 func (u *Unit) Close() error {
+	if u.closed {
+		return nil
+	}
 	if c, ok := u.SampleProcessor.(io.Closer); ok {
 		if err := c.Close(); err != nil {
 			return errors.Wrap(err, "close processor failed")
@@ -79,6 +84,7 @@ func (u *Unit) Close() error {
 			}
 		}
 	}
+	u.closed = true
 	return nil
 }
 
@@ -106,6 +112,11 @@ func (u *Unit) Attach(g *graph.Graph) error {
 	return nil
 }
 
+// AttachedTo returns whether or not this unit is attached to a Graph
+func (u *Unit) AttachedTo(g *graph.Graph) bool {
+	return g.Exists(u.node)
+}
+
 // Detach removes this unit and its inputs/outputs from a Graph
 func (u *Unit) Detach(g *graph.Graph) error {
 	if err := g.RemoveNode(u.node); err != nil {
@@ -113,11 +124,8 @@ func (u *Unit) Detach(g *graph.Graph) error {
 	}
 	for _, e := range u.In {
 		if e.HasSource() {
-			sources := e.node.InNeighbors()
-			for _, n := range sources {
-				if err := g.RemoveConnection(n, e.node); err != nil {
-					return errors.Wrap(err, "remove input connection failed")
-				}
+			if err := Unpatch(g, e); err != nil {
+				return errors.Wrap(err, "remove input connection failed")
 			}
 		}
 		if err := g.RemoveNode(e.node); err != nil {
@@ -125,7 +133,13 @@ func (u *Unit) Detach(g *graph.Graph) error {
 		}
 	}
 	for _, e := range u.Out {
-		if out := e.Out(); out.DestinationCount() > 0 {
+		out := e.Out()
+		for len(out.delayed) > 0 {
+			if err := Unpatch(g, out.delayed[0]); err != nil {
+				return errors.Wrap(err, "remove output feedback failed")
+			}
+		}
+		if out.DestinationCount() > 0 {
 			dests := out.node.OutNeighbors()
 			for _, n := range dests {
 				n.Value.(*In).Reset()
@@ -134,7 +148,7 @@ func (u *Unit) Detach(g *graph.Graph) error {
 				}
 			}
 		}
-		if err := g.RemoveNode(e.Out().node); err != nil {
+		if err := g.RemoveNode(out.node); err != nil {
 			return errors.Wrap(err, "remove output node failed")
 		}
 	}
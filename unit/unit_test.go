@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 
@@ -21,6 +22,27 @@ func TestRegisteredNames(t *testing.T) {
 	}
 }
 
+func TestBuilders_SeededRandom(t *testing.T) {
+	build := func(seed int64) []float64 {
+		var (
+			builders = Builders()
+			phases   []float64
+		)
+		for i := 0; i < 2; i++ {
+			u, err := builders["gen"](Config{SampleRate: sampleRate, FrameSize: frameSize, Seed: seed})
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("gen-%d", i), u.ID)
+			phases = append(phases, u.Out["sine"].(*genSine).phase)
+		}
+		return phases
+	}
+
+	a, b := build(1), build(1)
+	require.Equal(t, a, b)
+	require.NotEqual(t, a[0], a[1])
+	require.NotEqual(t, a, build(2))
+}
+
 func TestUnit_GraphAttachment(t *testing.T) {
 	io := NewIO("example", frameSize)
 	io.NewIn("in", dsp.Float64(0))
@@ -210,3 +232,41 @@ func TestUnit_Close(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 2, closeCalled)
 }
+
+func TestUnit_DetachFeedbackRemoval(t *testing.T) {
+	g := graph.New()
+
+	io1 := NewIO("example1", frameSize)
+	io1.NewIn("in", dsp.Float64(0))
+	io1.NewOut("out")
+	u1 := NewUnit(io1, nil)
+
+	io2 := NewIO("example2", frameSize)
+	io2.NewIn("in", dsp.Float64(0))
+	io2.NewOut("out")
+	u2 := NewUnit(io2, nil)
+
+	require.NoError(t, u1.Attach(g))
+	require.NoError(t, u2.Attach(g))
+
+	require.NoError(t, PatchFeedback(g, u1.Out["out"], u2.In["in"]))
+	require.True(t, u2.In["in"].Delayed())
+	require.Equal(t, 1, u1.ExternalNeighborCount())
+	require.Equal(t, 1, u2.ExternalNeighborCount())
+
+	// The delay is ordered ahead of both units; so they aren't ordered relative to each other.
+	require.NoError(t, Patch(g, u2.Out["out"], u1.In["in"]))
+	require.Nil(t, FeedbackLoop(g, u1))
+
+	// Patching the In directly replaces the delay.
+	require.NoError(t, Patch(g, u1.Out["out"], u2.In["in"]))
+	require.False(t, u2.In["in"].Delayed())
+	require.Len(t, FeedbackLoop(g, u1), 2)
+
+	require.NoError(t, PatchFeedback(g, u1.Out["out"], u2.In["in"]))
+	size := g.Size()
+	require.NoError(t, u1.Detach(g))
+	require.False(t, u2.In["in"].Delayed())
+	require.False(t, u2.In["in"].HasSource())
+	require.Equal(t, size-4, g.Size())
+}
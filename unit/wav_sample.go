@@ -22,29 +22,10 @@ func newWAVSample(io *IO, c Config) (*Unit, error) {
 		return nil, errors.New("no WAV file specified")
 	}
 
-	f, err := os.Open(config.File)
+	frame, channels, err := decodeWAV(config.File)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-
-	w := wav.NewDecoder(f)
-	if !w.IsValidFile() {
-		return nil, errors.Errorf("%q is not a valid WAV file", config.File)
-	}
-
-	buf, err := w.FullPCMBuffer()
-	if err != nil {
-		return nil, err
-	}
-
-	var (
-		raw   = buf.AsFloat32Buffer().Data
-		frame = make([]float64, len(raw))
-	)
-	for i, s := range raw {
-		frame[i] = float64(s)
-	}
 
 	return NewUnit(io, &wavSample{
 		trigger:     io.NewIn("trigger", dsp.Float64(-1)),
@@ -54,8 +35,8 @@ func newWAVSample(io *IO, c Config) (*Unit, error) {
 		cycle:       io.NewIn("cycle", dsp.Float64(0)),
 		a:           io.NewOut("a"),
 		b:           io.NewOut("b"),
-		channels:    buf.Format.NumChannels,
-		length:      buf.NumFrames(),
+		channels:    channels,
+		length:      len(frame) / channels,
 		frame:       frame,
 		lastTrigger: -1,
 	}), nil
@@ -139,3 +120,35 @@ func (w *wavSample) ProcessSample(i int) {
 
 	w.lastTrigger = trigger
 }
+
+// decodeWAV reads a WAV file, returning its interleaved samples and the number of channels.
+func decodeWAV(file string) ([]float64, int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	w := wav.NewDecoder(f)
+	if !w.IsValidFile() {
+		return nil, 0, errors.Errorf("%q is not a valid WAV file", file)
+	}
+
+	buf, err := w.FullPCMBuffer()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		raw      = buf.AsFloat32Buffer().Data
+		samples  = make([]float64, len(raw))
+		channels = buf.Format.NumChannels
+	)
+	if channels == 0 {
+		channels = 1
+	}
+	for i, s := range raw {
+		samples[i] = float64(s)
+	}
+	return samples, channels, nil
+}
@@ -0,0 +1,198 @@
+package unit
+
+import (
+	"math"
+
+	"github.com/brettbuddin/shaden/dsp"
+	"github.com/brettbuddin/shaden/errors"
+)
+
+const defaultWavetableSize = 2048
+
+func newWavetable(io *IO, c Config) (*Unit, error) {
+	var config struct {
+		File string
+		Size int
+	}
+	if err := c.Decode(&config); err != nil {
+		return nil, err
+	}
+
+	if config.File == "" {
+		return nil, errors.New("no WAV file specified")
+	}
+	if config.Size == 0 {
+		config.Size = defaultWavetableSize
+	}
+	if config.Size < 4 || !dsp.IsPowerOfTwo(config.Size) {
+		return nil, errors.Errorf("wavetable size %d is not a power of two", config.Size)
+	}
+
+	samples, channels, err := decodeWAV(config.File)
+	if err != nil {
+		return nil, err
+	}
+	frames := splitFrames(mixDown(samples, channels), config.Size)
+	if len(frames) == 0 {
+		return nil, errors.Errorf("%q contains no samples", config.File)
+	}
+
+	mipmaps := make([][][]float64, len(frames))
+	for i, f := range frames {
+		mipmaps[i] = newMipmap(f)
+	}
+
+	return NewUnit(io, &wavetable{
+		freq:     io.NewIn("freq", dsp.Frequency(440, c.SampleRate)),
+		fm:       io.NewIn("freq-mod", dsp.Float64(0)),
+		pm:       io.NewIn("phase-mod", dsp.Float64(0)),
+		sync:     io.NewIn("sync", dsp.Float64(-1)),
+		position: io.NewIn("position", dsp.Float64(0)),
+		amp:      io.NewIn("amp", dsp.Float64(1)),
+		offset:   io.NewIn("offset", dsp.Float64(0)),
+		out:      io.NewOut("out"),
+		size:     config.Size,
+		frames:   mipmaps,
+		phase:    c.Rand.Float64() * twoPi,
+	}), nil
+}
+
+type wavetable struct {
+	freq, fm, pm, sync, position, amp, offset *In
+	out                                       *Out
+
+	// frames holds the mipmap of each frame of the table; see newMipmap.
+	frames          [][][]float64
+	size            int
+	phase, lastSync float64
+}
+
+func (w *wavetable) ProcessSample(i int) {
+	var (
+		freq     = w.freq.Read(i)
+		fm       = w.fm.Read(i)
+		pm       = w.pm.Read(i)
+		sync     = w.sync.Read(i)
+		position = dsp.Clamp(w.position.Read(i), 0, 1)
+		amp      = w.amp.Read(i)
+		offset   = w.offset.Read(i)
+	)
+
+	if w.lastSync < 0 && sync > 0 && w.phase < math.Pi/2 {
+		w.phase = 0
+	}
+
+	var (
+		p       = wrapPhase((w.phase + pm) / twoPi)
+		level   = mipmapLevel(w.size, math.Abs(freq+fm))
+		pos     = position * float64(len(w.frames)-1)
+		begin   = int(pos)
+		end     = int(math.Min(float64(begin+1), float64(len(w.frames)-1)))
+		_, frac = math.Modf(pos)
+		next    = dsp.Lerp(
+			readTable(w.frames[begin], level, p),
+			readTable(w.frames[end], level, p),
+			frac,
+		)
+	)
+
+	w.phase = stepPhase(freq, fm, w.phase, 1, 1)
+	w.out.Write(i, (amp*next)+offset)
+	w.lastSync = sync
+}
+
+// mixDown averages the channels of interleaved samples.
+func mixDown(samples []float64, channels int) []float64 {
+	mono := make([]float64, len(samples)/channels)
+	for i := range mono {
+		for c := 0; c < channels; c++ {
+			mono[i] += samples[i*channels+c]
+		}
+		mono[i] /= float64(channels)
+	}
+	return mono
+}
+
+// splitFrames cuts samples into frames of a size. Samples that don't fill a whole frame are dropped; unless there are
+// fewer samples than a single frame holds, in which case they are taken to be a single cycle and are resampled to fit
+// the frame.
+func splitFrames(samples []float64, size int) [][]float64 {
+	if len(samples) == 0 {
+		return nil
+	}
+	if len(samples) < size {
+		frame := make([]float64, size)
+		for i := range frame {
+			pos := float64(i) * float64(len(samples)) / float64(size)
+			j := int(pos)
+			frame[i] = dsp.Lerp(samples[j], samples[(j+1)%len(samples)], pos-float64(j))
+		}
+		return [][]float64{frame}
+	}
+	frames := make([][]float64, len(samples)/size)
+	for i := range frames {
+		frames[i] = samples[i*size : (i+1)*size]
+	}
+	return frames
+}
+
+// newMipmap returns progressively band-limited copies of a single cycle waveform, whose length is a power of two. Each
+// level keeps half the harmonics of the one before it, down to only the fundamental. Levels are stored with at least
+// eight samples per cycle of their highest harmonic, so that interpolating between them stays accurate.
+func newMipmap(frame []float64) [][]float64 {
+	size := len(frame)
+	spectrum := make([]complex128, size)
+	for i, v := range frame {
+		spectrum[i] = complex(v, 0)
+	}
+	dsp.FFT(spectrum)
+
+	var levels [][]float64
+	for harmonics := size / 2; harmonics >= 1; harmonics /= 2 {
+		var (
+			length = size
+			bins   []complex128
+		)
+		if 8*harmonics < length {
+			length = 8 * harmonics
+		}
+		bins = make([]complex128, length)
+		bins[0] = spectrum[0]
+		for h := 1; h <= harmonics; h++ {
+			bins[h] = spectrum[h]
+			bins[length-h] = spectrum[size-h]
+		}
+		dsp.IFFT(bins)
+
+		scale := float64(length) / float64(size)
+		level := make([]float64, length)
+		for i, b := range bins {
+			level[i] = real(b) * scale
+		}
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// mipmapLevel returns the level of a mipmap for a table of a size that has no harmonics above the Nyquist frequency
+// when played with a phase increment (per sample) of dt.
+func mipmapLevel(size int, dt float64) int {
+	if dt*float64(size) <= 1 {
+		return 0
+	}
+	return int(math.Ceil(math.Log2(dt * float64(size))))
+}
+
+// readTable reads a level of a mipmap at phase p, where p is in the range [0, 1).
+func readTable(mipmap [][]float64, level int, p float64) float64 {
+	if level >= len(mipmap) {
+		level = len(mipmap) - 1
+	}
+	var (
+		table = mipmap[level]
+		mask  = len(table) - 1
+		pos   = p * float64(len(table))
+		j     = int(pos)
+	)
+	return dsp.Hermite(table[(j-1)&mask], table[j&mask], table[(j+1)&mask], table[(j+2)&mask], pos-float64(j))
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brettbuddin/shaden/dsp"
+)
+
+func TestWavetable_Position(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shaden")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sine, silence := make([]float64, 256), make([]float64, 256)
+	for i := range sine {
+		sine[i] = math.Sin(twoPi * float64(i) / 256)
+	}
+	u := newTestWavetable(t, writeWavetable(t, dir, sine, silence), 256)
+	u.In["freq"].Fill(dsp.Frequency(441, sampleRate))
+
+	peak := func(position float64) float64 {
+		u.In["position"].Fill(dsp.Float64(position))
+		var peak float64
+		for i := 0; i < frameSize; i++ {
+			u.ProcessSample(i)
+			peak = math.Max(peak, math.Abs(u.Out["out"].Out().Read(i)))
+		}
+		return peak
+	}
+	require.InDelta(t, 1, peak(0), 0.01)
+	require.InDelta(t, 0.5, peak(0.5), 0.01)
+	require.InDelta(t, 0, peak(1), 0.01)
+}
+
+func TestWavetable_Antialias(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shaden")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	saw := make([]float64, defaultWavetableSize)
+	for i := range saw {
+		saw[i] = 0.9 * (2*float64(i)/float64(len(saw)) - 1)
+	}
+	u := newTestWavetable(t, writeWavetable(t, dir, saw), 0)
+
+	const f0 = 2489
+	u.In["freq"].Fill(dsp.Frequency(f0, sampleRate))
+	signal := make([]float64, 0, aliasingSize)
+	for len(signal) < aliasingSize {
+		for i := 0; i < frameSize; i++ {
+			u.ProcessSample(i)
+			signal = append(signal, u.Out["out"].Out().Read(i))
+		}
+	}
+	require.True(t, aliasing(signal, f0) < -55, "aliasing: %f dB", aliasing(signal, f0))
+}
+
+func TestWavetable_SingleCycle(t *testing.T) {
+	cycle := []float64{0, 1, 0, -1}
+	frames := splitFrames(cycle, 8)
+	require.Len(t, frames, 1)
+	require.Equal(t, []float64{0, 0.5, 1, 0.5, 0, -0.5, -1, -0.5}, frames[0])
+
+	frames = splitFrames(append(cycle, 1, 2), 2)
+	require.Equal(t, [][]float64{{0, 1}, {0, -1}, {1, 2}}, frames)
+	require.Nil(t, splitFrames(nil, 2))
+}
+
+func TestWavetable_Mipmap(t *testing.T) {
+	square := make([]float64, 64)
+	for i := range square {
+		square[i] = 1
+		if i >= 32 {
+			square[i] = -1
+		}
+	}
+	mipmap := newMipmap(square)
+	require.Len(t, mipmap, 6)
+	require.Equal(t, 64, len(mipmap[0]))
+	require.Equal(t, 8, len(mipmap[len(mipmap)-1]))
+
+	// The last level holds only the fundamental.
+	fundamental := mipmap[len(mipmap)-1]
+	require.InDelta(t, 0, fundamental[0], 0.1)
+	require.InDelta(t, 4/math.Pi, fundamental[2], 0.05)
+	require.InDelta(t, 0, fundamental[4], 0.1)
+	require.InDelta(t, -4/math.Pi, fundamental[6], 0.05)
+
+	require.Equal(t, 0, mipmapLevel(64, 1.0/128))
+	require.Equal(t, 1, mipmapLevel(64, 1.0/48))
+	require.Equal(t, 5, mipmapLevel(64, 0.5))
+}
+
+func TestWavetable_Errors(t *testing.T) {
+	builder := Builders()["wavetable"]
+	tests := []map[string]interface{}{
+		{},
+		{"file": "missing.wav"},
+		{"file": "missing.wav", "size": 100},
+	}
+	for _, values := range tests {
+		_, err := builder(Config{
+			Values:     values,
+			SampleRate: sampleRate,
+			FrameSize:  frameSize,
+			Rand:       rand.New(rand.NewSource(1)),
+		})
+		require.Error(t, err)
+	}
+}
+
+func newTestWavetable(t *testing.T, file string, size int) *Unit {
+	values := map[string]interface{}{"file": file}
+	if size > 0 {
+		values["size"] = size
+	}
+	u, err := Builders()["wavetable"](Config{
+		Values:     values,
+		SampleRate: sampleRate,
+		FrameSize:  frameSize,
+		Rand:       rand.New(rand.NewSource(1)),
+	})
+	require.NoError(t, err)
+	return u
+}
+
+// writeWavetable writes frames to a 16-bit mono WAV file.
+func writeWavetable(t *testing.T, dir string, frames ...[]float64) string {
+	path := filepath.Join(dir, "table.wav")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	buf := &audio.IntBuffer{
+		Format:         &audio.Format{NumChannels: 1, SampleRate: sampleRate},
+		SourceBitDepth: 16,
+	}
+	for _, frame := range frames {
+		for _, v := range frame {
+			buf.Data = append(buf.Data, int(math.Round(v*math.MaxInt16)))
+		}
+	}
+	enc := wav.NewEncoder(f, sampleRate, 16, 1, 1)
+	require.NoError(t, enc.Write(buf))
+	require.NoError(t, enc.Close())
+	return path
+}